@@ -1,6 +1,7 @@
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -12,17 +13,164 @@ type GitOpsSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
+	// ImageSpec is the image to update, unless images are specified
+	ImageSpec `json:",inline"`
+	// Images are the images to update in a single commit, each with its own tag format and policy
+	// +optional
+	Images []ImageSpec `json:"images,omitempty"`
+
+	GitRepo          string `json:"git_repo"`
+	GitBranch        string `json:"git_branch"`
+	GitReleaseBranch string `json:"git_release_branch,omitempty"`
+	// GitCredentialsSecretRef refers to the Secret which has the credentials for git_repo instead of
+	// GITOPS_GIT_USERNAME and GITOPS_GIT_PASSWORD. The Secret is kubernetes.io/basic-auth, kubernetes.io/ssh-auth
+	// (with "known_hosts", and "token" to create the PR), or has "token", or "app_id", "installation_id" and
+	// "private_key" of the GitHub App.
+	// +optional
+	GitCredentialsSecretRef *corev1.LocalObjectReference `json:"git_credentials_secret_ref,omitempty"`
+	// GitSSHKey refers to the Secret which has the private key (e.g. a deploy key) and known_hosts,
+	// which are used for the ssh:// or git@ repository URL
+	// +optional
+	GitSSHKey *GitSSHKey `json:"git_ssh_key,omitempty"`
+	// GitPaths are the files to update, which may be glob patterns (e.g. "overlays/*/kustomization.yaml",
+	// "**/values-*.yaml") or directories
+	GitPaths       []string `json:"git_paths"`
+	GitCommitName  string   `json:"git_commit_name"`
+	GitCommitEmail string   `json:"git_commit_email"`
+	// GitCommitPolicy is "latest" (default) to commit only the newest tag, or "every" to commit every tag from oldest
+	GitCommitPolicy string `json:"git_commit_policy,omitempty"`
+	// GitCommitMessageTemplate is the text/template of the commit message, which can use .Namespace, .Name,
+	// .Image (the first image), .Images ({.Name, .Path, .Registry, .OldTag, .NewTag, .Digest}) and .Files
+	GitCommitMessageTemplate string `json:"git_commit_message_template,omitempty"`
+	// GitPRTitleTemplate is the text/template of the PR title, which can use the same data as the commit message
+	GitPRTitleTemplate string `json:"git_pr_title_template,omitempty"`
+	// GitPRBodyTemplate is the text/template of the PR body, which can use the same data as the commit message
+	GitPRBodyTemplate string `json:"git_pr_body_template,omitempty"`
+	// GitProvider is the service to create the PR, "github", "gitlab", "bitbucket" (Bitbucket Cloud),
+	// "bitbucket-server" (Bitbucket Server and Data Center) or "gitea" (Gitea and Forgejo),
+	// which is detected from the host of git_repo by default
+	// +optional
+	GitProvider string `json:"git_provider,omitempty"`
+	// GitAPIURL is the base URL of the API of the self-hosted git_provider such as "https://github.example.com/api/v3/"
+	// (GitHub Enterprise Server), "https://gitlab.example.com/api/v4", "https://bitbucket.example.com/rest/api/1.0"
+	// or "https://gitea.example.com/api/v1", which is derived from the host of git_repo by default
+	// +optional
+	GitAPIURL string `json:"git_api_url,omitempty"`
+	// GitPRLabels are the labels of the PR
+	// +optional
+	GitPRLabels []string `json:"git_pr_labels,omitempty"`
+	// GitPRAssignees are the usernames to assign the PR to, who are the reviewers for Bitbucket Server
+	// +optional
+	GitPRAssignees []string `json:"git_pr_assignees,omitempty"`
+	// GitPRRemoveSourceBranch removes the branch of the PR after merged (GitLab and Bitbucket Cloud)
+	// +optional
+	GitPRRemoveSourceBranch bool `json:"git_pr_remove_source_branch,omitempty"`
+	// GitSigningKey refers to the Secret which has the private key to sign the commits
+	// +optional
+	GitSigningKey *GitSigningKey `json:"git_signing_key,omitempty"`
+
+	// GitUpdater is the format of the files in git_paths, "kustomize" (default), "helm", "manifest" or "marker"
+	// ("marker" updates the lines commented with {"$gitops": "<namespace>:<name>"} or {"$gitops": "<namespace>:<name>:<image_name>:tag"})
+	GitUpdater string `json:"git_updater,omitempty"`
+}
+
+// ImageSpec defines the image to update and how to find its new tags
+type ImageSpec struct {
+	RegistryType string `json:"registry_type,omitempty"`
+	// RegistryPlainHTTP connects to the registry over plain HTTP instead of HTTPS (e.g. a local registry)
+	RegistryPlainHTTP bool `json:"registry_plain_http,omitempty"`
+
+	RegistryUsername string `json:"registry_username,omitempty"`
+	// RegistryPasswordSecretRef refers to the key of the password of registry_username in the Secret in the namespace
+	// of the GitOps for registry_type "docker" and "dockerhub" (default: GITOPS_DOCKERHUB_USERNAME and
+	// GITOPS_DOCKERHUB_PASSWORD for "dockerhub", or GITOPS_REGISTRY_USERNAME and GITOPS_REGISTRY_PASSWORD
+	// only for the host of GITOPS_REGISTRY_HOST)
+	RegistryPasswordSecretRef *corev1.SecretKeySelector `json:"registry_password_secret_ref,omitempty"`
+
 	AWSProfile string `json:"aws_profile,omitempty"`
 
-	ImagePath      string `json:"image_path"`
-	ImageTagFormat string `json:"image_tag_format"`
+	// GCPCredentialsSecretRef refers to the key of the service account key JSON in the Secret in the namespace
+	// of the GitOps (default: the application default credentials such as workload identity)
+	GCPCredentialsSecretRef *corev1.SecretKeySelector `json:"gcp_credentials_secret_ref,omitempty"`
+
+	AzureTenantID string `json:"azure_tenant_id,omitempty"`
+	AzureClientID string `json:"azure_client_id,omitempty"`
+	// AzureClientSecretRef refers to the key of the client secret of the service principal in the Secret
+	// in the namespace of the GitOps (default: AZURE_CLIENT_SECRET or the workload identity)
+	AzureClientSecretRef *corev1.SecretKeySelector `json:"azure_client_secret_ref,omitempty"`
+
+	// ImageName is the name to identify the image in the marker comments
+	ImageName      string         `json:"image_name,omitempty"`
+	ImagePath      string         `json:"image_path,omitempty"`
+	ImageTagFormat string         `json:"image_tag_format,omitempty"`
+	ImageTagRegex  *ImageTagRegex `json:"image_tag_regex,omitempty"`
+	// ImageTagCalVerLayout is the layout for image_tag_format "calver" such as "YYYY.0M.0D" or "YY.0M.MICRO"
+	ImageTagCalVerLayout string `json:"image_tag_calver_layout,omitempty"`
+
+	// ImageTag is the mutable tag (e.g. "latest") to follow by the digest for image_tag_format "digest"
+	ImageTag string `json:"image_tag,omitempty"`
+	// ImagePinDigest pins the tag by the digest, so that re-pushes to the tag are committed
+	ImagePinDigest bool `json:"image_pin_digest,omitempty"`
+	// ImageDigestMode is where to write the digest, "tag" (newTag: <tag>@<digest>, default) or "digest" (kustomize digest field)
+	ImageDigestMode string `json:"image_digest_mode,omitempty"`
+
+	// ImageTagConstraint limits semantic tags to the range such as ">=1.2.0 <2.0.0" or "~1.4"
+	ImageTagConstraint string `json:"image_tag_constraint,omitempty"`
+	// ImageTagAllowPreRelease allows semantic pre-release tags such as "v2.0.0-rc.1"
+	ImageTagAllowPreRelease bool `json:"image_tag_allow_pre_release,omitempty"`
+
+	// GitValueKeys maps the path or the glob pattern in git_paths to the key of the image tag in Helm values
+	// such as "app.deployment.image.tag" (default: "image.tag"). The tag is not updated if "repository"
+	// (with "registry") or "image" next to it is another image than image_path, and the images of the same key
+	// without the repository are an error.
+	GitValueKeys map[string]string `json:"git_value_keys,omitempty"`
+}
+
+// ImageTagRegex defines the tag pattern for image_tag_format "regex"
+type ImageTagRegex struct {
+	// Pattern is a regular expression that must match the whole tag
+	Pattern string `json:"pattern"`
+	// Groups are the named capture groups to compare in order (default: all named groups, numerically)
+	// +optional
+	Groups []ImageTagRegexGroup `json:"groups,omitempty"`
+}
+
+// ImageTagRegexGroup defines how to compare the named capture group
+type ImageTagRegexGroup struct {
+	Name string `json:"name"`
+	// Order is "numerical" (default) or "lexical"
+	// +optional
+	Order string `json:"order,omitempty"`
+}
 
-	GitRepo          string   `json:"git_repo"`
-	GitBranch        string   `json:"git_branch"`
-	GitReleaseBranch string   `json:"git_release_branch,omitempty"`
-	GitPaths         []string `json:"git_paths"`
-	GitCommitName    string   `json:"git_commit_name"`
-	GitCommitEmail   string   `json:"git_commit_email"`
+// GitSSHKey defines the SSH private key and known_hosts in the Secret
+type GitSSHKey struct {
+	// SecretName is the name of the Secret in the namespace of the GitOps
+	SecretName string `json:"secret_name"`
+	// Key is the key of the private key in the Secret (default: "ssh-privatekey")
+	// +optional
+	Key string `json:"key,omitempty"`
+	// KnownHostsKey is the key of the known_hosts in the Secret (default: "known_hosts")
+	// +optional
+	KnownHostsKey string `json:"known_hosts_key,omitempty"`
+	// PassphraseKey is the key of the passphrase in the Secret if the private key is encrypted (default: "passphrase")
+	// +optional
+	PassphraseKey string `json:"passphrase_key,omitempty"`
+}
+
+// GitSigningKey defines the private key in the Secret to sign the commits
+type GitSigningKey struct {
+	// Format is "openpgp" (default) for an armored OpenPGP private key, or "ssh" for an SSH private key
+	// +optional
+	Format string `json:"format,omitempty"`
+	// SecretName is the name of the Secret in the namespace of the GitOps
+	SecretName string `json:"secret_name"`
+	// Key is the key of the private key in the Secret (default: "signing.key")
+	// +optional
+	Key string `json:"key,omitempty"`
+	// PassphraseKey is the key of the passphrase in the Secret if the private key is encrypted (default: "passphrase")
+	// +optional
+	PassphraseKey string `json:"passphrase_key,omitempty"`
 }
 
 // GitOpsStatus defines the observed state of GitOps
@@ -31,6 +179,43 @@ type GitOpsStatus struct {
 	// Important: Run "make" to regenerate code after modifying this file
 	// +optional
 	CurrentTag string `json:"current_tag"`
+	// +optional
+	CurrentDigest string `json:"current_digest,omitempty"`
+	// Images are the current tags of the images
+	// +optional
+	Images []ImageStatus `json:"images,omitempty"`
+	// +optional
+	Conditions []GitOpsCondition `json:"conditions,omitempty"`
+}
+
+// ImageStatus defines the current tag of the image
+type ImageStatus struct {
+	ImagePath string `json:"image_path"`
+	// +optional
+	CurrentTag string `json:"current_tag,omitempty"`
+	// +optional
+	CurrentDigest string `json:"current_digest,omitempty"`
+}
+
+// GitOpsConditionType is the type of GitOpsCondition
+type GitOpsConditionType string
+
+const (
+	// GitOpsPathsMissing is true when some of git_paths match no file, which is checked only when there are
+	// new versions to commit since the repository is not cloned otherwise (unknown until the first check)
+	GitOpsPathsMissing GitOpsConditionType = "PathsMissing"
+)
+
+// GitOpsCondition describes the state of GitOps at a certain point
+type GitOpsCondition struct {
+	Type   GitOpsConditionType    `json:"type"`
+	Status corev1.ConditionStatus `json:"status"`
+	// +optional
+	LastTransitionTime metav1.Time `json:"last_transition_time,omitempty"`
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -13,6 +13,7 @@ http://opensource.org/licenses/mit-license.php
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -22,7 +23,7 @@ func (in *GitOps) DeepCopyInto(out *GitOps) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOps.
@@ -43,6 +44,22 @@ func (in *GitOps) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitOpsCondition) DeepCopyInto(out *GitOpsCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsCondition.
+func (in *GitOpsCondition) DeepCopy() *GitOpsCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(GitOpsCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsList) DeepCopyInto(out *GitOpsList) {
 	*out = *in
@@ -78,11 +95,44 @@ func (in *GitOpsList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsSpec) DeepCopyInto(out *GitOpsSpec) {
 	*out = *in
+	in.ImageSpec.DeepCopyInto(&out.ImageSpec)
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]ImageSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.GitCredentialsSecretRef != nil {
+		in, out := &in.GitCredentialsSecretRef, &out.GitCredentialsSecretRef
+		*out = new(corev1.LocalObjectReference)
+		**out = **in
+	}
+	if in.GitSSHKey != nil {
+		in, out := &in.GitSSHKey, &out.GitSSHKey
+		*out = new(GitSSHKey)
+		**out = **in
+	}
 	if in.GitPaths != nil {
 		in, out := &in.GitPaths, &out.GitPaths
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.GitPRLabels != nil {
+		in, out := &in.GitPRLabels, &out.GitPRLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GitPRAssignees != nil {
+		in, out := &in.GitPRAssignees, &out.GitPRAssignees
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.GitSigningKey != nil {
+		in, out := &in.GitSigningKey, &out.GitSigningKey
+		*out = new(GitSigningKey)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsSpec.
@@ -98,6 +148,18 @@ func (in *GitOpsSpec) DeepCopy() *GitOpsSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GitOpsStatus) DeepCopyInto(out *GitOpsStatus) {
 	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]ImageStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]GitOpsCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitOpsStatus.
@@ -109,3 +171,125 @@ func (in *GitOpsStatus) DeepCopy() *GitOpsStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSSHKey) DeepCopyInto(out *GitSSHKey) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSSHKey.
+func (in *GitSSHKey) DeepCopy() *GitSSHKey {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSSHKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GitSigningKey) DeepCopyInto(out *GitSigningKey) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GitSigningKey.
+func (in *GitSigningKey) DeepCopy() *GitSigningKey {
+	if in == nil {
+		return nil
+	}
+	out := new(GitSigningKey)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageSpec) DeepCopyInto(out *ImageSpec) {
+	*out = *in
+	if in.RegistryPasswordSecretRef != nil {
+		in, out := &in.RegistryPasswordSecretRef, &out.RegistryPasswordSecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AzureClientSecretRef != nil {
+		in, out := &in.AzureClientSecretRef, &out.AzureClientSecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GCPCredentialsSecretRef != nil {
+		in, out := &in.GCPCredentialsSecretRef, &out.GCPCredentialsSecretRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageTagRegex != nil {
+		in, out := &in.ImageTagRegex, &out.ImageTagRegex
+		*out = new(ImageTagRegex)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GitValueKeys != nil {
+		in, out := &in.GitValueKeys, &out.GitValueKeys
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageSpec.
+func (in *ImageSpec) DeepCopy() *ImageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageStatus) DeepCopyInto(out *ImageStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageStatus.
+func (in *ImageStatus) DeepCopy() *ImageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageTagRegex) DeepCopyInto(out *ImageTagRegex) {
+	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]ImageTagRegexGroup, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageTagRegex.
+func (in *ImageTagRegex) DeepCopy() *ImageTagRegex {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageTagRegex)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageTagRegexGroup) DeepCopyInto(out *ImageTagRegexGroup) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageTagRegexGroup.
+func (in *ImageTagRegexGroup) DeepCopy() *ImageTagRegexGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageTagRegexGroup)
+	in.DeepCopyInto(out)
+	return out
+}
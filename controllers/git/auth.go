@@ -0,0 +1,82 @@
+package git
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	plumbing_http "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	plumbing_ssh "gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+// SSHKey is the private key and the known hosts for the SSH repository URL
+type SSHKey struct {
+	PrivateKey []byte
+	Passphrase []byte
+	KnownHosts []byte
+}
+
+// isSSHURL reports whether the repository URL uses the SSH transport,
+// such as "ssh://git@github.com/owner/repo.git" or "git@github.com:owner/repo.git"
+func isSSHURL(repo string) bool {
+	endpoint, err := transport.NewEndpoint(repo)
+	return err == nil && endpoint.Protocol == "ssh"
+}
+
+// newAuth chooses the auth method from the scheme of the repository URL
+func newAuth(c Config) (transport.AuthMethod, error) {
+	if isSSHURL(c.Repo) {
+		if c.SSHKey == nil {
+			return nil, errors.New("SSH key is required for the SSH repository URL")
+		}
+		if len(c.SSHKey.KnownHosts) == 0 {
+			return nil, errors.New("known_hosts is required to verify the SSH host key")
+		}
+		endpoint, err := transport.NewEndpoint(c.Repo)
+		if err != nil {
+			return nil, err
+		}
+		user := endpoint.User
+		if user == "" {
+			user = "git"
+		}
+		auth, err := plumbing_ssh.NewPublicKeys(user, c.SSHKey.PrivateKey, string(c.SSHKey.Passphrase))
+		if err != nil {
+			return nil, err
+		}
+		auth.HostKeyCallback, err = knownHostsCallback(c.SSHKey.KnownHosts)
+		if err != nil {
+			return nil, err
+		}
+		return auth, nil
+	}
+
+	if c.Username != "" && c.Password != "" {
+		return &plumbing_http.BasicAuth{
+			Username: c.Username,
+			Password: c.Password,
+		}, nil
+	}
+	return nil, nil
+}
+
+// knownHostsCallback verifies the host key with the known_hosts content.
+// knownhosts reads only files, so the content is written to a temporary file.
+func knownHostsCallback(knownHosts []byte) (ssh.HostKeyCallback, error) {
+	file, err := ioutil.TempFile("", "known_hosts")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.Write(knownHosts); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	return knownhosts.New(file.Name())
+}
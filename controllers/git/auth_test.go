@@ -0,0 +1,93 @@
+package git
+
+import (
+	"net"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	plumbing_http "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	plumbing_ssh "gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+func TestIsSSHURL(t *testing.T) {
+	tests := []struct {
+		repo   string
+		result bool
+	}{
+		{"https://github.com/kazylla/gitops.git", false},
+		{"git@github.com:kazylla/gitops.git", true},
+		{"ssh://git@github.com/kazylla/gitops.git", true},
+		{"/tmp/gitops", false},
+	}
+	for _, test := range tests {
+		t.Run(test.repo, func(t *testing.T) {
+			if result := isSSHURL(test.repo); result != test.result {
+				t.Errorf("expected %v, got %v", test.result, result)
+			}
+		})
+	}
+}
+
+func TestNewAuth(t *testing.T) {
+	privateKey := newSSHKey(t)
+	signer, err := ssh.ParsePrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	otherSigner, err := ssh.ParsePrivateKey(newSSHKey(t))
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	knownHosts := []byte(knownhosts.Line([]string{"github.com"}, signer.PublicKey()) + "\n")
+
+	t.Run("ssh", func(t *testing.T) {
+		auth, err := newAuth(Config{
+			Repo:   "git@github.com:kazylla/gitops.git",
+			SSHKey: &SSHKey{PrivateKey: privateKey, KnownHosts: knownHosts},
+		})
+		if err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+		publicKeys, ok := auth.(*plumbing_ssh.PublicKeys)
+		if !ok {
+			t.Fatalf("expected *ssh.PublicKeys, got %T", auth)
+		}
+		if publicKeys.User != "git" {
+			t.Errorf("expected git, got %s", publicKeys.User)
+		}
+		addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 22}
+		if err := publicKeys.HostKeyCallback("github.com:22", addr, signer.PublicKey()); err != nil {
+			t.Errorf("got unexpected error: %s", err.Error())
+		}
+		if err := publicKeys.HostKeyCallback("github.com:22", addr, otherSigner.PublicKey()); err == nil {
+			t.Errorf("expected error for unknown host key, got nil")
+		}
+	})
+
+	t.Run("https", func(t *testing.T) {
+		auth, err := newAuth(Config{
+			Repo:     "https://github.com/kazylla/gitops.git",
+			Username: "user",
+			Password: "password",
+		})
+		if err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+		if _, ok := auth.(*plumbing_http.BasicAuth); !ok {
+			t.Errorf("expected *http.BasicAuth, got %T", auth)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for _, c := range []Config{
+			{Repo: "git@github.com:kazylla/gitops.git"},
+			{Repo: "git@github.com:kazylla/gitops.git", SSHKey: &SSHKey{PrivateKey: privateKey}},
+			{Repo: "git@github.com:kazylla/gitops.git", SSHKey: &SSHKey{PrivateKey: []byte("invalid"), KnownHosts: knownHosts}},
+		} {
+			if _, err := newAuth(c); err == nil {
+				t.Errorf("expected error for %v, got nil", c.SSHKey)
+			}
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package git
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// BitbucketPR creates pull requests on Bitbucket Cloud
+type BitbucketPR struct {
+	RepoURL  string
+	Username string
+	Password string
+	// BaseURL is the URL of the Bitbucket Cloud API (default: "https://api.bitbucket.org/2.0")
+	BaseURL string
+}
+
+func NewBitbucketPR(repoURL, apiURL, username, password string) *BitbucketPR {
+	if apiURL == "" {
+		apiURL = "https://api.bitbucket.org/2.0"
+	}
+	return &BitbucketPR{
+		RepoURL:  repoURL,
+		Username: username,
+		Password: password,
+		BaseURL:  apiURL,
+	}
+}
+
+// bitbucketBranch is the branch of the pull request on Bitbucket Cloud
+type bitbucketBranch struct {
+	Branch struct {
+		Name string `json:"name"`
+	} `json:"branch"`
+}
+
+// bitbucketPullRequest is the request body to create a pull request on Bitbucket Cloud
+type bitbucketPullRequest struct {
+	Title             string          `json:"title"`
+	Description       string          `json:"description"`
+	Source            bitbucketBranch `json:"source"`
+	Destination       bitbucketBranch `json:"destination"`
+	CloseSourceBranch bool            `json:"close_source_branch"`
+}
+
+// CreatePR creates a pull request for the specified branch.
+// The app password is used with the username, and labels and assignees are not supported.
+func (pr *BitbucketPR) CreatePR(pullRequest PullRequest) error {
+	repoURL, err := parseRepoURL(pr.RepoURL)
+	if err != nil {
+		return err
+	}
+
+	newPR := bitbucketPullRequest{
+		Title:             pullRequest.Title,
+		Description:       pullRequest.Body,
+		CloseSourceBranch: pullRequest.RemoveSourceBranch,
+	}
+	newPR.Source.Branch.Name = pullRequest.Head
+	newPR.Destination.Branch.Name = pullRequest.Base
+
+	u := fmt.Sprintf("%s/repositories/%s/%s/pullrequests", strings.TrimSuffix(pr.BaseURL, "/"),
+		url.PathEscape(repoURL.Owner), url.PathEscape(repoURL.RepoName))
+	return doJSON("POST", u, func(req *http.Request) {
+		req.SetBasicAuth(pr.Username, pr.Password)
+	}, newPR, nil)
+}
+
+// BitbucketServerPR creates pull requests on Bitbucket Server and Data Center
+type BitbucketServerPR struct {
+	RepoURL  string
+	Username string
+	Password string
+	// BaseURL is the URL of the Bitbucket Server API such as "https://bitbucket.example.com/rest/api/1.0"
+	BaseURL string
+}
+
+func NewBitbucketServerPR(repoURL, apiURL, username, password string) *BitbucketServerPR {
+	pr := &BitbucketServerPR{
+		RepoURL:  repoURL,
+		Username: username,
+		Password: password,
+		BaseURL:  apiURL,
+	}
+	if u, err := parseRepoURL(repoURL); err == nil && apiURL == "" {
+		pr.BaseURL = fmt.Sprintf("https://%s/rest/api/1.0", u.Host)
+	}
+	return pr
+}
+
+// bitbucketServerRef is the branch of the pull request on Bitbucket Server
+type bitbucketServerRef struct {
+	ID         string `json:"id"`
+	Repository struct {
+		Slug    string `json:"slug"`
+		Project struct {
+			Key string `json:"key"`
+		} `json:"project"`
+	} `json:"repository"`
+}
+
+// bitbucketServerReviewer is the reviewer of the pull request on Bitbucket Server
+type bitbucketServerReviewer struct {
+	User struct {
+		Name string `json:"name"`
+	} `json:"user"`
+}
+
+// bitbucketServerPullRequest is the request body to create a pull request on Bitbucket Server
+type bitbucketServerPullRequest struct {
+	Title       string                    `json:"title"`
+	Description string                    `json:"description"`
+	FromRef     bitbucketServerRef        `json:"fromRef"`
+	ToRef       bitbucketServerRef        `json:"toRef"`
+	Reviewers   []bitbucketServerReviewer `json:"reviewers,omitempty"`
+}
+
+// CreatePR creates a pull request for the specified branch with the personal access token.
+// The assignees are added as the reviewers, and labels are not supported.
+func (pr *BitbucketServerPR) CreatePR(pullRequest PullRequest) error {
+	repoURL, err := parseRepoURL(pr.RepoURL)
+	if err != nil {
+		return err
+	}
+
+	// the clone URL is ".../scm/<project>/<repo>.git" for https, or ".../<project>/<repo>.git" for ssh
+	projectKey := path.Base(repoURL.Owner)
+	ref := func(branch string) bitbucketServerRef {
+		r := bitbucketServerRef{ID: "refs/heads/" + branch}
+		r.Repository.Slug = repoURL.RepoName
+		r.Repository.Project.Key = projectKey
+		return r
+	}
+	newPR := bitbucketServerPullRequest{
+		Title:       pullRequest.Title,
+		Description: pullRequest.Body,
+		FromRef:     ref(pullRequest.Head),
+		ToRef:       ref(pullRequest.Base),
+	}
+	for _, assignee := range pullRequest.Assignees {
+		var reviewer bitbucketServerReviewer
+		reviewer.User.Name = assignee
+		newPR.Reviewers = append(newPR.Reviewers, reviewer)
+	}
+
+	u := fmt.Sprintf("%s/projects/%s/repos/%s/pull-requests", strings.TrimSuffix(pr.BaseURL, "/"),
+		url.PathEscape(projectKey), url.PathEscape(repoURL.RepoName))
+	return doJSON("POST", u, func(req *http.Request) {
+		req.Header.Set("Authorization", "Bearer "+pr.Password)
+	}, newPR, nil)
+}
@@ -0,0 +1,86 @@
+package git
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBitbucketPR_CreatePR(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "app-password" {
+			t.Errorf("unexpected basic auth: %s %s", username, password)
+		}
+		if r.Method != "POST" || r.URL.Path != "/2.0/repositories/kazylla/gitops/pullrequests" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("got unexpected error: %s", err.Error())
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	pr := NewBitbucketPR("https://user@bitbucket.org/kazylla/gitops.git", "", "user", "app-password")
+	pr.BaseURL = server.URL + "/2.0"
+	err := pr.CreatePR(PullRequest{
+		Title:              "Release Candidate: v1.1.0",
+		Body:               "If you want to deploy version v1.1.0, please merge this PR",
+		Head:               "release-v1.1.0",
+		Base:               "master",
+		RemoveSourceBranch: true,
+	})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	buf, _ := json.Marshal(body)
+	expected := `{"close_source_branch":true,"description":"If you want to deploy version v1.1.0, please merge this PR","destination":{"branch":{"name":"master"}},"source":{"branch":{"name":"release-v1.1.0"}},"title":"Release Candidate: v1.1.0"}`
+	if string(buf) != expected {
+		t.Errorf("expected %s, got %s", expected, string(buf))
+	}
+}
+
+func TestBitbucketServerPR_CreatePR(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+			t.Errorf("unexpected authorization: %s", auth)
+		}
+		if r.Method != "POST" || r.URL.Path != "/rest/api/1.0/projects/PROJ/repos/gitops/pull-requests" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("got unexpected error: %s", err.Error())
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	pr := NewBitbucketServerPR("https://bitbucket.example.com/scm/PROJ/gitops.git", "", "user", "token")
+	if pr.BaseURL != "https://bitbucket.example.com/rest/api/1.0" {
+		t.Errorf("unexpected base URL: %s", pr.BaseURL)
+	}
+	pr.BaseURL = server.URL + "/rest/api/1.0"
+	err := pr.CreatePR(PullRequest{
+		Title:     "Release Candidate: v1.1.0",
+		Body:      "If you want to deploy version v1.1.0, please merge this PR",
+		Head:      "release-v1.1.0",
+		Base:      "master",
+		Assignees: []string{"alice"},
+	})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	buf, _ := json.Marshal(body)
+	expected := `{"description":"If you want to deploy version v1.1.0, please merge this PR",` +
+		`"fromRef":{"id":"refs/heads/release-v1.1.0","repository":{"project":{"key":"PROJ"},"slug":"gitops"}},` +
+		`"reviewers":[{"user":{"name":"alice"}}],"title":"Release Candidate: v1.1.0",` +
+		`"toRef":{"id":"refs/heads/master","repository":{"project":{"key":"PROJ"},"slug":"gitops"}}}`
+	if string(buf) != expected {
+		t.Errorf("expected %s, got %s", expected, string(buf))
+	}
+}
@@ -0,0 +1,122 @@
+package git
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// GiteaPR creates pull requests on Gitea and Forgejo
+type GiteaPR struct {
+	RepoURL  string
+	Username string
+	Password string
+	// BaseURL is the URL of the Gitea API such as "https://gitea.example.com/api/v1"
+	BaseURL string
+}
+
+func NewGiteaPR(repoURL, apiURL, username, password string) *GiteaPR {
+	pr := &GiteaPR{
+		RepoURL:  repoURL,
+		Username: username,
+		Password: password,
+		BaseURL:  apiURL,
+	}
+	if u, err := parseRepoURL(repoURL); err == nil && apiURL == "" {
+		pr.BaseURL = fmt.Sprintf("https://%s/api/v1", u.Host)
+	}
+	return pr
+}
+
+// giteaPullRequest is the request body to create a pull request
+type giteaPullRequest struct {
+	Head      string   `json:"head"`
+	Base      string   `json:"base"`
+	Title     string   `json:"title"`
+	Body      string   `json:"body"`
+	Assignees []string `json:"assignees,omitempty"`
+	Labels    []int64  `json:"labels,omitempty"`
+}
+
+// CreatePR creates a pull request for the specified branch with the access token
+func (pr *GiteaPR) CreatePR(pullRequest PullRequest) error {
+	repoURL, err := parseRepoURL(pr.RepoURL)
+	if err != nil {
+		return err
+	}
+	repo := fmt.Sprintf("/repos/%s/%s", url.PathEscape(repoURL.Owner), url.PathEscape(repoURL.RepoName))
+
+	// the labels are specified by the IDs, which are looked up in the labels of the repository and the organization
+	var labelIDs []int64
+	if len(pullRequest.Labels) > 0 {
+		labels, err := pr.listLabels(repo + "/labels")
+		if err != nil {
+			return err
+		}
+		orgLabels, err := pr.listLabels(fmt.Sprintf("/orgs/%s/labels", url.PathEscape(repoURL.Owner)))
+		if apiErr, ok := err.(*apiError); ok && apiErr.StatusCode == http.StatusNotFound {
+			// the owner is a user, not an organization
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+		labels = append(labels, orgLabels...)
+
+		for _, name := range pullRequest.Labels {
+			found := false
+			for _, label := range labels {
+				if label.Name == name {
+					labelIDs = append(labelIDs, label.ID)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("gitea label %s is not found", name)
+			}
+		}
+	}
+
+	newPR := giteaPullRequest{
+		Head:      pullRequest.Head,
+		Base:      pullRequest.Base,
+		Title:     pullRequest.Title,
+		Body:      pullRequest.Body,
+		Assignees: pullRequest.Assignees,
+		Labels:    labelIDs,
+	}
+	return pr.do("POST", repo+"/pulls", newPR, nil)
+}
+
+// giteaLabel is the label of the repository or the organization
+type giteaLabel struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// giteaPageLimit is the number of items per page, which the server may lower by MAX_RESPONSE_ITEMS
+const giteaPageLimit = 50
+
+// listLabels gets the labels of all pages until an empty page
+func (pr *GiteaPR) listLabels(path string) ([]giteaLabel, error) {
+	labels := make([]giteaLabel, 0)
+	for page := 1; ; page++ {
+		var pageLabels []giteaLabel
+		if err := pr.do("GET", fmt.Sprintf("%s?page=%d&limit=%d", path, page, giteaPageLimit), nil, &pageLabels); err != nil {
+			return nil, err
+		}
+		if len(pageLabels) == 0 {
+			return labels, nil
+		}
+		labels = append(labels, pageLabels...)
+	}
+}
+
+// do sends the request to the Gitea API with the access token
+func (pr *GiteaPR) do(method, path string, in, out interface{}) error {
+	return doJSON(method, strings.TrimSuffix(pr.BaseURL, "/")+path, func(req *http.Request) {
+		req.Header.Set("Authorization", "token "+pr.Password)
+	}, in, out)
+}
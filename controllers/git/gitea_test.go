@@ -0,0 +1,96 @@
+package git
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGiteaPR_CreatePR(t *testing.T) {
+	var pullRequest giteaPullRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "token token" {
+			t.Errorf("unexpected authorization: %s", auth)
+		}
+		page := r.URL.Query().Get("page")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/repos/kazylla/gitops/labels":
+			switch page {
+			case "1":
+				_, _ = w.Write([]byte(`[{"id": 3, "name": "release"}]`))
+			case "2":
+				_, _ = w.Write([]byte(`[{"id": 5, "name": "bot"}]`))
+			default:
+				_, _ = w.Write([]byte(`[]`))
+			}
+		case r.Method == "GET" && r.URL.Path == "/api/v1/orgs/kazylla/labels":
+			if page == "1" {
+				_, _ = w.Write([]byte(`[{"id": 7, "name": "gitops"}]`))
+			} else {
+				_, _ = w.Write([]byte(`[]`))
+			}
+		case r.Method == "GET" && r.URL.Path == "/api/v1/repos/alice/gitops/labels":
+			if page == "1" {
+				_, _ = w.Write([]byte(`[{"id": 9, "name": "release"}]`))
+			} else {
+				_, _ = w.Write([]byte(`[]`))
+			}
+		case r.Method == "GET" && r.URL.Path == "/api/v1/orgs/alice/labels":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == "POST" && (r.URL.Path == "/api/v1/repos/kazylla/gitops/pulls" || r.URL.Path == "/api/v1/repos/alice/gitops/pulls"):
+			if err := json.NewDecoder(r.Body).Decode(&pullRequest); err != nil {
+				t.Errorf("got unexpected error: %s", err.Error())
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"number": 1}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	pr := NewGiteaPR("https://gitea.example.com/kazylla/gitops.git", "", "user", "token")
+	if pr.BaseURL != "https://gitea.example.com/api/v1" {
+		t.Errorf("unexpected base URL: %s", pr.BaseURL)
+	}
+	pr = NewGiteaPR("https://gitea.example.com/kazylla/gitops.git", server.URL+"/api/v1", "user", "token")
+
+	err := pr.CreatePR(PullRequest{
+		Title:     "Release Candidate: v1.1.0",
+		Body:      "If you want to deploy version v1.1.0, please merge this PR",
+		Head:      "release-v1.1.0",
+		Base:      "master",
+		Labels:    []string{"bot", "release", "gitops"},
+		Assignees: []string{"alice"},
+	})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	expected := giteaPullRequest{
+		Head:      "release-v1.1.0",
+		Base:      "master",
+		Title:     "Release Candidate: v1.1.0",
+		Body:      "If you want to deploy version v1.1.0, please merge this PR",
+		Assignees: []string{"alice"},
+		Labels:    []int64{5, 3, 7},
+	}
+	if !reflect.DeepEqual(pullRequest, expected) {
+		t.Errorf("expected %+v, got %+v", expected, pullRequest)
+	}
+
+	if err := pr.CreatePR(PullRequest{Head: "release-v1.1.0", Base: "master", Labels: []string{"unknown"}}); err == nil {
+		t.Errorf("expected error for unknown label, got nil")
+	}
+
+	// the repository of a user has no organization labels
+	pr = NewGiteaPR("https://gitea.example.com/alice/gitops.git", server.URL+"/api/v1", "user", "token")
+	if err := pr.CreatePR(PullRequest{Head: "release-v1.1.0", Base: "master", Labels: []string{"release"}}); err != nil {
+		t.Errorf("got unexpected error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(pullRequest.Labels, []int64{9}) {
+		t.Errorf("expected [9], got %v", pullRequest.Labels)
+	}
+}
@@ -2,38 +2,63 @@ package git
 
 import (
 	"context"
-	"fmt"
+	"net/http"
 
 	"github.com/google/go-github/github"
+	"golang.org/x/oauth2"
 )
 
 type GithubPR struct {
 	RepoURL  string
 	Username string
 	Password string
+	// BasicAuth authenticates by Username and Password (GITOPS_GIT_USERNAME and GITOPS_GIT_PASSWORD,
+	// or the kubernetes.io/basic-auth secret), otherwise Password is sent as the token
+	BasicAuth bool
+	// BaseURL is the URL of the GitHub Enterprise Server API such as "https://github.example.com/api/v3/",
+	// or empty for github.com
+	BaseURL string
 }
 
-func NewGithubPR(repoURL, username, password string) *GithubPR {
+func NewGithubPR(repoURL, apiURL, username, password string) *GithubPR {
 	return &GithubPR{
 		RepoURL:  repoURL,
 		Username: username,
 		Password: password,
+		BaseURL:  apiURL,
 	}
 }
 
+// newGithubClient creates the client for github.com, or for GitHub Enterprise Server if baseURL is given
+func newGithubClient(baseURL string, httpClient *http.Client) (*github.Client, error) {
+	if baseURL == "" {
+		return github.NewClient(httpClient), nil
+	}
+	return github.NewEnterpriseClient(baseURL, baseURL, httpClient)
+}
+
 // CreatePR creates a pull request for the specified branch
-func (pr *GithubPR) CreatePR(tag, branch string) error {
-	tp := github.BasicAuthTransport{
-		Username: pr.Username,
-		Password: pr.Password,
+func (pr *GithubPR) CreatePR(pullRequest PullRequest) error {
+	// the password is the personal access token or the installation token of the GitHub App unless BasicAuth
+	ctx := context.Background()
+	httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: pr.Password}))
+	if pr.BasicAuth {
+		tp := github.BasicAuthTransport{
+			Username: pr.Username,
+			Password: pr.Password,
+		}
+		httpClient = tp.Client()
+	}
+	client, err := newGithubClient(pr.BaseURL, httpClient)
+	if err != nil {
+		return err
 	}
-	client := github.NewClient(tp.Client())
 
 	newPR := &github.NewPullRequest{
-		Title:               github.String(fmt.Sprintf("Release Candidate: %s", tag)),
-		Head:                github.String(branch),
-		Base:                github.String("master"),
-		Body:                github.String(fmt.Sprintf("If you want to deploy version %s, please merge this PR", tag)),
+		Title:               github.String(pullRequest.Title),
+		Head:                github.String(pullRequest.Head),
+		Base:                github.String(pullRequest.Base),
+		Body:                github.String(pullRequest.Body),
 		MaintainerCanModify: github.Bool(true),
 	}
 
@@ -42,10 +67,24 @@ func (pr *GithubPR) CreatePR(tag, branch string) error {
 		return err
 	}
 
-	_, _, err = client.PullRequests.Create(context.Background(), repoURL.Owner, repoURL.RepoName, newPR)
+	created, _, err := client.PullRequests.Create(ctx, repoURL.Owner, repoURL.RepoName, newPR)
 	if err != nil {
 		return err
 	}
 
+	// labels and assignees are set to the issue of the pull request
+	if len(pullRequest.Labels) > 0 {
+		_, _, err = client.Issues.AddLabelsToIssue(ctx, repoURL.Owner, repoURL.RepoName, created.GetNumber(), pullRequest.Labels)
+		if err != nil {
+			return err
+		}
+	}
+	if len(pullRequest.Assignees) > 0 {
+		_, _, err = client.Issues.AddAssignees(ctx, repoURL.Owner, repoURL.RepoName, created.GetNumber(), pullRequest.Assignees)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,142 @@
+package git
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+// gitHubAppUsername is the username to push with the installation token
+const gitHubAppUsername = "x-access-token"
+
+// GitHubApp is the installation of the GitHub App to authenticate as
+type GitHubApp struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     []byte
+	// BaseURL is the URL of the GitHub Enterprise Server API, or empty for github.com
+	BaseURL string
+}
+
+// installationToken is the token of the installation which expires at ExpiresAt
+type installationToken struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// installationTokens caches the tokens by the app, the installation and the private key across reconciles
+var installationTokens = struct {
+	sync.Mutex
+	tokens map[string]installationToken
+}{tokens: make(map[string]installationToken)}
+
+// Token returns the cached installation token, or creates a new one if it expires within a few minutes.
+// The private key is used to sign the JWT even if the token is cached, and the cached token is returned
+// only for the same private key, so that the IDs of another app do not reveal its token.
+func (app *GitHubApp) Token() (string, error) {
+	jwt, err := app.jwt(time.Now())
+	if err != nil {
+		return "", err
+	}
+	keyHash := sha256.Sum256(app.PrivateKey)
+	key := fmt.Sprintf("%s/%d/%d/%x", app.BaseURL, app.AppID, app.InstallationID, keyHash)
+
+	installationTokens.Lock()
+	defer installationTokens.Unlock()
+
+	if t, ok := installationTokens.tokens[key]; ok && time.Now().Add(5*time.Minute).Before(t.ExpiresAt) {
+		return t.Token, nil
+	}
+	t, err := app.createToken(jwt)
+	if err != nil {
+		return "", err
+	}
+	installationTokens.tokens[key] = t
+	return t.Token, nil
+}
+
+// createToken creates a new installation token authenticated by the JWT of the app
+func (app *GitHubApp) createToken(jwt string) (installationToken, error) {
+	ctx := context.Background()
+	client, err := newGithubClient(app.BaseURL, oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: jwt})))
+	if err != nil {
+		return installationToken{}, err
+	}
+
+	req, err := client.NewRequest("POST", fmt.Sprintf("app/installations/%d/access_tokens", app.InstallationID), nil)
+	if err != nil {
+		return installationToken{}, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	token := new(github.InstallationToken)
+	if _, err := client.Do(ctx, req, token); err != nil {
+		return installationToken{}, err
+	}
+	if token.GetToken() == "" {
+		return installationToken{}, errors.New("no installation token in the response")
+	}
+	return installationToken{Token: token.GetToken(), ExpiresAt: token.GetExpiresAt()}, nil
+}
+
+// jwt creates the JSON Web Token of the app signed by the private key with RS256
+func (app *GitHubApp) jwt(now time.Time) (string, error) {
+	privateKey, err := parseRSAPrivateKey(app.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	// issued 60 seconds in the past to allow for clock drift, and expires within the maximum 10 minutes
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-60 * time.Second).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": app.AppID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey parses the PEM encoded PKCS #1 or PKCS #8 RSA private key
+func parseRSAPrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM encoded private key found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("the private key is not RSA")
+	}
+	return rsaKey, nil
+}
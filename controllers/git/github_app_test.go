@@ -0,0 +1,159 @@
+package git
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitHubApp_Token(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	privateKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tests := []struct {
+		name           string
+		installationID int64
+		expiresIn      time.Duration
+		requests       int
+	}{
+		{
+			"cached token",
+			1,
+			time.Hour,
+			1,
+		},
+		{
+			"token expiring soon",
+			2,
+			time.Minute,
+			2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				if r.Method != "POST" || r.URL.Path != fmt.Sprintf("/app/installations/%d/access_tokens", test.installationID) {
+					t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+				}
+				verifyJWT(t, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), &key.PublicKey, 12345)
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"token":      fmt.Sprintf("token-%d", requests),
+					"expires_at": time.Now().Add(test.expiresIn).Format(time.RFC3339),
+				})
+			}))
+			defer server.Close()
+
+			app := &GitHubApp{
+				AppID:          12345,
+				InstallationID: test.installationID,
+				PrivateKey:     privateKey,
+				BaseURL:        server.URL,
+			}
+			for i := 0; i < 2; i++ {
+				token, err := app.Token()
+				if err != nil {
+					t.Fatalf("got unexpected error: %s", err.Error())
+				}
+				if expected := fmt.Sprintf("token-%d", requests); token != expected {
+					t.Errorf("expected %s, got %s", expected, token)
+				}
+			}
+			if requests != test.requests {
+				t.Errorf("expected %d requests, got %d", test.requests, requests)
+			}
+		})
+	}
+}
+
+func TestGitHubApp_Token_PrivateKey(t *testing.T) {
+	var privateKeys [][]byte
+	var publicKeys []*rsa.PublicKey
+	for i := 0; i < 2; i++ {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+		privateKeys = append(privateKeys, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+		publicKeys = append(publicKeys, &key.PublicKey)
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		verifyJWT(t, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), publicKeys[requests-1], 12345)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":      fmt.Sprintf("token-%d", requests),
+			"expires_at": time.Now().Add(time.Hour).Format(time.RFC3339),
+		})
+	}))
+	defer server.Close()
+
+	// the apps of the same IDs with the different private keys get their own tokens
+	for i, privateKey := range privateKeys {
+		app := &GitHubApp{AppID: 12345, InstallationID: 3, PrivateKey: privateKey, BaseURL: server.URL}
+		token, err := app.Token()
+		if err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+		if expected := fmt.Sprintf("token-%d", i+1); token != expected {
+			t.Errorf("expected %s, got %s", expected, token)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+
+	// the invalid private key gets no cached token
+	app := &GitHubApp{AppID: 12345, InstallationID: 3, PrivateKey: []byte("invalid"), BaseURL: server.URL}
+	if token, err := app.Token(); err == nil {
+		t.Errorf("expected error, got %s", token)
+	}
+}
+
+// verifyJWT verifies the RS256 signature and the issuer of the JSON Web Token
+func verifyJWT(t *testing.T, jwt string, publicKey *rsa.PublicKey, appID int64) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("invalid JWT: %s", jwt)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash[:], signature); err != nil {
+		t.Errorf("got unexpected error: %s", err.Error())
+	}
+	buf, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	var claims map[string]int64
+	if err := json.Unmarshal(buf, &claims); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if claims["iss"] != appID {
+		t.Errorf("expected %d, got %d", appID, claims["iss"])
+	}
+	if claims["exp"]-claims["iat"] > 600 {
+		t.Errorf("JWT expires after more than 10 minutes: %v", claims)
+	}
+}
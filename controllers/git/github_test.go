@@ -0,0 +1,70 @@
+package git
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGithubPR_CreatePR(t *testing.T) {
+	requests := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if auth := r.Header.Get("Authorization"); auth != "Bearer token" {
+			t.Errorf("unexpected authorization: %s", auth)
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		w.WriteHeader(http.StatusCreated)
+		switch r.URL.Path {
+		case "/api/v3/repos/kazylla/gitops/issues/2/labels":
+			_, _ = w.Write([]byte(`[{"name": "release"}]`))
+		default:
+			_, _ = w.Write([]byte(`{"number": 2}`))
+		}
+	}))
+	defer server.Close()
+
+	// GitHub Enterprise Server
+	pr := NewGithubPR("https://github.example.com/kazylla/gitops.git", server.URL+"/api/v3/", "user", "token")
+	err := pr.CreatePR(PullRequest{
+		Title:     "Release Candidate: v1.1.0",
+		Body:      "If you want to deploy version v1.1.0, please merge this PR",
+		Head:      "release-v1.1.0",
+		Base:      "master",
+		Labels:    []string{"release"},
+		Assignees: []string{"alice"},
+	})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	expected := []string{
+		"POST /api/v3/repos/kazylla/gitops/pulls",
+		"POST /api/v3/repos/kazylla/gitops/issues/2/labels",
+		"POST /api/v3/repos/kazylla/gitops/issues/2/assignees",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("expected %v, got %v", expected, requests)
+	}
+}
+
+func TestGithubPR_CreatePRBasicAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username, password, ok := r.BasicAuth(); !ok || username != "user" || password != "password" {
+			t.Errorf("unexpected basic auth: %s %s", username, password)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"number": 2}`))
+	}))
+	defer server.Close()
+
+	pr := NewGithubPR("https://github.example.com/kazylla/gitops.git", server.URL+"/api/v3/", "user", "password")
+	pr.BasicAuth = true
+	err := pr.CreatePR(PullRequest{
+		Title: "Release Candidate: v1.1.0",
+		Head:  "release-v1.1.0",
+		Base:  "master",
+	})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+}
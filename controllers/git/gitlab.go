@@ -0,0 +1,91 @@
+package git
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type GitlabPR struct {
+	RepoURL  string
+	Username string
+	Password string
+	// BaseURL is the URL of the GitLab API such as "https://gitlab.example.com/api/v4"
+	BaseURL string
+}
+
+func NewGitlabPR(repoURL, apiURL, username, password string) *GitlabPR {
+	pr := &GitlabPR{
+		RepoURL:  repoURL,
+		Username: username,
+		Password: password,
+		BaseURL:  apiURL,
+	}
+	if u, err := parseRepoURL(repoURL); err == nil && apiURL == "" {
+		pr.BaseURL = fmt.Sprintf("https://%s/api/v4", u.Host)
+	}
+	return pr
+}
+
+// gitlabMergeRequest is the request body to create a merge request
+type gitlabMergeRequest struct {
+	SourceBranch       string  `json:"source_branch"`
+	TargetBranch       string  `json:"target_branch"`
+	Title              string  `json:"title"`
+	Description        string  `json:"description"`
+	Labels             string  `json:"labels,omitempty"`
+	AssigneeIDs        []int64 `json:"assignee_ids,omitempty"`
+	RemoveSourceBranch bool    `json:"remove_source_branch"`
+}
+
+// CreatePR creates a merge request for the specified branch
+func (pr *GitlabPR) CreatePR(pullRequest PullRequest) error {
+	repoURL, err := parseRepoURL(pr.RepoURL)
+	if err != nil {
+		return err
+	}
+
+	// the assignees are specified by the user IDs
+	assigneeIDs := make([]int64, 0, len(pullRequest.Assignees))
+	for _, username := range pullRequest.Assignees {
+		id, err := pr.userID(username)
+		if err != nil {
+			return err
+		}
+		assigneeIDs = append(assigneeIDs, id)
+	}
+
+	mergeRequest := gitlabMergeRequest{
+		SourceBranch:       pullRequest.Head,
+		TargetBranch:       pullRequest.Base,
+		Title:              pullRequest.Title,
+		Description:        pullRequest.Body,
+		Labels:             strings.Join(pullRequest.Labels, ","),
+		AssigneeIDs:        assigneeIDs,
+		RemoveSourceBranch: pullRequest.RemoveSourceBranch,
+	}
+	project := url.PathEscape(repoURL.Owner + "/" + repoURL.RepoName)
+	return pr.do("POST", fmt.Sprintf("/projects/%s/merge_requests", project), mergeRequest, nil)
+}
+
+// userID finds the ID of the user by the username
+func (pr *GitlabPR) userID(username string) (int64, error) {
+	var users []struct {
+		ID int64 `json:"id"`
+	}
+	if err := pr.do("GET", "/users?username="+url.QueryEscape(username), nil, &users); err != nil {
+		return 0, err
+	}
+	if len(users) == 0 {
+		return 0, fmt.Errorf("gitlab user %s is not found", username)
+	}
+	return users[0].ID, nil
+}
+
+// do sends the request to the GitLab API with the personal access token
+func (pr *GitlabPR) do(method, path string, in, out interface{}) error {
+	return doJSON(method, strings.TrimSuffix(pr.BaseURL, "/")+path, func(req *http.Request) {
+		req.Header.Set("PRIVATE-TOKEN", pr.Password)
+	}, in, out)
+}
@@ -0,0 +1,71 @@
+package git
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGitlabPR_CreatePR(t *testing.T) {
+	var mergeRequest gitlabMergeRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token := r.Header.Get("PRIVATE-TOKEN"); token != "token" {
+			t.Errorf("unexpected token: %s", token)
+		}
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v4/users":
+			if r.URL.Query().Get("username") != "alice" {
+				_, _ = w.Write([]byte("[]"))
+				return
+			}
+			_, _ = w.Write([]byte(`[{"id": 7, "username": "alice"}]`))
+		case r.Method == "POST" && r.URL.EscapedPath() == "/api/v4/projects/group%2Fsubgroup%2Fgitops/merge_requests":
+			if err := json.NewDecoder(r.Body).Decode(&mergeRequest); err != nil {
+				t.Errorf("got unexpected error: %s", err.Error())
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"iid": 1}`))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.EscapedPath())
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	pr := NewGitlabPR("https://gitlab.example.com/group/subgroup/gitops.git", "", "user", "token")
+	if pr.BaseURL != "https://gitlab.example.com/api/v4" {
+		t.Errorf("unexpected base URL: %s", pr.BaseURL)
+	}
+	pr.BaseURL = server.URL + "/api/v4"
+
+	err := pr.CreatePR(PullRequest{
+		Title:              "Release Candidate: v1.1.0",
+		Body:               "If you want to deploy version v1.1.0, please merge this PR",
+		Head:               "release-v1.1.0",
+		Base:               "master",
+		Labels:             []string{"release", "bot"},
+		Assignees:          []string{"alice"},
+		RemoveSourceBranch: true,
+	})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	expected := gitlabMergeRequest{
+		SourceBranch:       "release-v1.1.0",
+		TargetBranch:       "master",
+		Title:              "Release Candidate: v1.1.0",
+		Description:        "If you want to deploy version v1.1.0, please merge this PR",
+		Labels:             "release,bot",
+		AssigneeIDs:        []int64{7},
+		RemoveSourceBranch: true,
+	}
+	if !reflect.DeepEqual(mergeRequest, expected) {
+		t.Errorf("expected %+v, got %+v", expected, mergeRequest)
+	}
+
+	if err := pr.CreatePR(PullRequest{Head: "release-v1.1.0", Base: "master", Assignees: []string{"bob"}}); err == nil {
+		t.Errorf("expected error for unknown assignee, got nil")
+	}
+}
@@ -0,0 +1,254 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/kazylla/gitops-controller/controllers/version"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultValueKey = "image.tag"
+
+// HelmUpdater updates the image tag at the configured key of Helm values.yaml
+type HelmUpdater struct {
+	config Config
+	log    logr.Logger
+}
+
+// parseValueKey parses the dotted or JSONPath-style key (e.g. "image.tag", "$.app.containers[0].image.tag",
+// `annotations["example.com/tag"]`) into the map keys (string) and the sequence indexes (int)
+func parseValueKey(key string) ([]interface{}, error) {
+	s := strings.TrimPrefix(key, "$")
+	segments := make([]interface{}, 0)
+	for s != "" {
+		switch {
+		case strings.HasPrefix(s, "."):
+			s = s[1:]
+		case strings.HasPrefix(s, `["`) || strings.HasPrefix(s, `['`):
+			quote := s[1:2]
+			end := strings.Index(s[2:], quote+"]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid value key: %s", key)
+			}
+			segments = append(segments, s[2:end+2])
+			s = s[end+4:]
+		case strings.HasPrefix(s, "["):
+			end := strings.Index(s, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid value key: %s", key)
+			}
+			index, err := strconv.Atoi(s[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid value key: %s", key)
+			}
+			segments = append(segments, index)
+			s = s[end+1:]
+		default:
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			segments = append(segments, s[:end])
+			s = s[end:]
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid value key: %s", key)
+	}
+	if _, ok := segments[len(segments)-1].(string); !ok {
+		return nil, fmt.Errorf("value key must end with a map key: %s", key)
+	}
+	return segments, nil
+}
+
+// valueKey returns the key of the image tag for the path, which is looked up by the path itself,
+// or by the glob pattern in git_paths which the path is expanded from
+func (u *HelmUpdater) valueKey(path string, image Image) string {
+	patterns := make([]string, 0, len(image.ValueKeys))
+	for pattern, key := range image.ValueKeys {
+		if key == "" {
+			continue
+		}
+		if cleanPath(pattern) == path {
+			return key
+		}
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if matchPath(cleanPath(pattern), path) {
+			return image.ValueKeys[pattern]
+		}
+	}
+	return defaultValueKey
+}
+
+// repositoryOf returns the image repository next to the tag, which is "repository" (with "registry" if any)
+// or "image", and false if the mapping has neither
+func repositoryOf(node *yaml.Node) (string, bool) {
+	repo := mappingValue(node, "repository")
+	if repo == nil || repo.Kind != yaml.ScalarNode {
+		repo = mappingValue(node, "image")
+	}
+	if repo == nil || repo.Kind != yaml.ScalarNode || repo.Value == "" {
+		return "", false
+	}
+	name, _, _ := splitImage(repo.Value)
+	if registry := mappingValue(node, "registry"); registry != nil && registry.Kind == yaml.ScalarNode && registry.Value != "" {
+		name = strings.TrimSuffix(registry.Value, "/") + "/" + name
+	}
+	return name, true
+}
+
+// normalizeRepository removes the Docker Hub host and "library/" of the official images, so that
+// "docker.io/bitnami/nginx" is the same repository as "bitnami/nginx"
+func normalizeRepository(repo string) string {
+	for _, host := range []string{"docker.io/", "index.docker.io/", "registry-1.docker.io/"} {
+		repo = strings.TrimPrefix(repo, host)
+	}
+	return strings.TrimPrefix(repo, "library/")
+}
+
+// valueMapping returns the mapping which has the tag at the key segments, or nil if there is none
+func valueMapping(node *yaml.Node, segments []interface{}) *yaml.Node {
+	for _, segment := range segments[:len(segments)-1] {
+		switch s := segment.(type) {
+		case string:
+			node = mappingValue(node, s)
+		case int:
+			if node.Kind != yaml.SequenceNode || s >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[s]
+		}
+		if node == nil {
+			return nil
+		}
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	return node
+}
+
+// checkValueKeys returns an error if more than one image has the same value key in the values,
+// which has no repository to distinguish them so that the images would overwrite the tag each other
+func (u *HelmUpdater) checkValueKeys(path string, buf []byte, images []Image) error {
+	imagesByKey := make(map[string][]string)
+	keys := make([]string, 0)
+	for _, image := range images {
+		key := u.valueKey(path, image)
+		if _, ok := imagesByKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		imagesByKey[key] = append(imagesByKey[key], image.Path)
+	}
+
+	doc, err := parseYAMLDocument(buf)
+	if err != nil || len(doc.roots()) == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		if len(imagesByKey[key]) < 2 {
+			continue
+		}
+		segments, err := parseValueKey(key)
+		if err != nil {
+			return err
+		}
+		node := valueMapping(doc.roots()[0], segments)
+		if node == nil {
+			continue
+		}
+		if _, ok := repositoryOf(node); !ok {
+			return fmt.Errorf("images %s have the same value key %s without the repository in %s, which requires git_value_keys",
+				strings.Join(imagesByKey[key], ", "), key, path)
+		}
+	}
+	return nil
+}
+
+// Update updates the image tag at the value key
+func (u *HelmUpdater) Update(path string, buf []byte, image Image, imageVer version.ImageVersion) ([]byte, string, bool, error) {
+	log := u.log
+	registryTag := imageVer.GetTag()
+	registryDigest := digestOf(imageVer)
+
+	key := u.valueKey(path, image)
+	segments, err := parseValueKey(key)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	doc, err := parseYAMLDocument(buf)
+	if err != nil || len(doc.roots()) == 0 {
+		log.Info("unable to parse values, ignored", "path", path)
+		return nil, "", false, nil
+	}
+
+	// find the mapping which has the tag
+	node := valueMapping(doc.roots()[0], segments)
+	if node == nil {
+		log.Info("there is no value key, ignored", "path", path, "key", key)
+		return nil, "", false, nil
+	}
+	tagKey := segments[len(segments)-1].(string)
+
+	// the values of another image may have the same key, which is distinguished by the repository
+	if repo, ok := repositoryOf(node); ok && normalizeRepository(repo) != normalizeRepository(image.Path) {
+		log.V(1).Info("the repository is not the image, ignored", "path", path, "key", key, "repository", repo)
+		return nil, "", false, nil
+	}
+
+	// continue if the version on git repository is newer
+	tag := mappingValue(node, tagKey)
+	digest := mappingValue(node, "digest")
+	var currentTag string
+	if tag != nil && tag.Value != "" {
+		var currentDigest string
+		currentTag, currentDigest = version.SplitDigest(tag.Value)
+		if digest != nil && image.DigestMode == DigestModeField {
+			currentDigest = digest.Value
+		}
+		newer, err := isNewer(imageVer, currentTag, currentDigest)
+		if err != nil {
+			return nil, "", false, err
+		}
+		if !newer {
+			log.V(1).Info("this tag is older than current", "current", currentTag, "this tag", registryTag)
+			return nil, "", false, nil
+		}
+	} else {
+		log.Info("since tag was not found, create it", "key", key, "tag", registryTag)
+	}
+
+	// update version
+	if tag != nil {
+		err = doc.setScalar(tag, tagWithDigest(imageVer, image.DigestMode))
+	} else {
+		err = doc.addKey(node, tagKey, tagWithDigest(imageVer, image.DigestMode))
+	}
+	if err != nil {
+		return nil, "", false, err
+	}
+	if registryDigest != "" && image.DigestMode == DigestModeField {
+		if digest != nil {
+			err = doc.setScalar(digest, registryDigest)
+		} else {
+			err = doc.addKey(node, "digest", registryDigest)
+		}
+		if err != nil {
+			return nil, "", false, err
+		}
+	}
+
+	writeBuf, err := doc.bytes()
+	if err != nil {
+		return nil, "", false, err
+	}
+	return writeBuf, currentTag, true, nil
+}
@@ -0,0 +1,329 @@
+package git
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kazylla/gitops-controller/controllers/version"
+	"gopkg.in/yaml.v2"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestParseValueKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		key      string
+		segments []interface{}
+	}{
+		{
+			"dotted key",
+			"app.deployment.image.tag",
+			[]interface{}{"app", "deployment", "image", "tag"},
+		},
+		{
+			"JSONPath-style key",
+			"$.app.containers[0].image.tag",
+			[]interface{}{"app", "containers", 0, "image", "tag"},
+		},
+		{
+			"bracketed key with dots",
+			`podAnnotations["example.com/tag"]`,
+			[]interface{}{"podAnnotations", "example.com/tag"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			segments, err := parseValueKey(test.key)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if !reflect.DeepEqual(segments, test.segments) {
+				t.Errorf("expected %v, got %v", test.segments, segments)
+			}
+		})
+	}
+
+	for _, key := range []string{"", "$", "image[0]", "image[x].tag", `image["tag`} {
+		if _, err := parseValueKey(key); err == nil {
+			t.Errorf("expected error for %q, got nil", key)
+		}
+	}
+}
+
+func TestHelmUpdater_valueKey(t *testing.T) {
+	image := Image{ValueKeys: map[string]string{
+		"charts/app/values.yaml":    "app.image.tag",
+		"./envs/*/values.yaml":      "envs.image.tag",
+		"**/values-production.yaml": "production.image.tag",
+	}}
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"charts/app/values.yaml", "app.image.tag"},
+		{"envs/staging/values.yaml", "envs.image.tag"},
+		{"charts/app/values-production.yaml", "production.image.tag"},
+		{"charts/web/values.yaml", defaultValueKey},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			u := &HelmUpdater{
+				log: log.NullLogger{},
+			}
+			if key := u.valueKey(test.path, image); key != test.key {
+				t.Errorf("expected %s, got %s", test.key, key)
+			}
+		})
+	}
+}
+
+func TestHelmUpdater_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		values  string
+		tag     string
+		updated bool
+		result  string
+	}{
+		{
+			"default key",
+			"",
+			"image:\n  repository: xxx/app\n  tag: v1.0.0\n",
+			"v1.1.0",
+			true,
+			"v1.1.0",
+		},
+		{
+			"nested key",
+			"app.deployment.image.tag",
+			"app:\n  deployment:\n    image:\n      tag: v1.0.0\n",
+			"v1.1.0",
+			true,
+			"v1.1.0",
+		},
+		{
+			"sequence index",
+			"$.containers[1].tag",
+			"containers:\n- tag: v1.0.0\n- tag: v1.0.0\n",
+			"v1.1.0",
+			true,
+			"v1.1.0",
+		},
+		{
+			"current tag is newer",
+			"",
+			"image:\n  tag: v1.2.0\n",
+			"v1.1.0",
+			false,
+			"",
+		},
+		{
+			"missing tag is created",
+			"",
+			"image:\n  repository: xxx/app\n",
+			"v1.1.0",
+			true,
+			"v1.1.0",
+		},
+		{
+			"missing parent is ignored",
+			"app.image.tag",
+			"image:\n  tag: v1.0.0\n",
+			"v1.1.0",
+			false,
+			"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &HelmUpdater{
+				log: log.NullLogger{},
+			}
+			image := Image{Path: "xxx/app", ValueKeys: map[string]string{"values.yaml": test.key}}
+			imageVer, err := version.NewSemanticImageVersion(test.tag)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			buf, _, updated, err := u.Update("values.yaml", []byte(test.values), image, imageVer)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if updated != test.updated {
+				t.Fatalf("expected updated %v, got %v", test.updated, updated)
+			}
+			if !updated {
+				return
+			}
+
+			key := test.key
+			if key == "" {
+				key = defaultValueKey
+			}
+			segments, _ := parseValueKey(key)
+			var node interface{}
+			if err := yaml.Unmarshal(buf, &node); err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			for _, segment := range segments {
+				switch s := segment.(type) {
+				case string:
+					node = node.(map[interface{}]interface{})[s]
+				case int:
+					node = node.([]interface{})[s]
+				}
+			}
+			if node != test.result {
+				t.Errorf("expected %s, got %v", test.result, node)
+			}
+		})
+	}
+}
+
+func TestHelmUpdater_UpdateImages(t *testing.T) {
+	u := &HelmUpdater{
+		log: log.NullLogger{},
+	}
+	values := "app:\n  image:\n    repository: xxx/app\n    tag: v1.0.0\n" +
+		"worker:\n  image:\n    registry: registry.example.com\n    repository: xxx/worker\n    tag: v2.0.0\n" +
+		"proxy:\n  image: nginx\n  tag: v3.0.0\n" +
+		"hub:\n  image:\n    registry: docker.io\n    repository: bitnami/nginx\n    tag: v4.0.0\n" +
+		"cache:\n  image:\n    repository: docker.io/library/redis\n    tag: v5.0.0\n"
+	images := []struct {
+		image  Image
+		tag    string
+		result string
+	}{
+		{
+			Image{Path: "xxx/app", ValueKeys: map[string]string{"values.yaml": "app.image.tag"}},
+			"v1.1.0",
+			"app:\n  image:\n    repository: xxx/app\n    tag: v1.1.0\n",
+		},
+		{
+			Image{Path: "registry.example.com/xxx/worker", ValueKeys: map[string]string{"values.yaml": "worker.image.tag"}},
+			"v2.1.0",
+			"    registry: registry.example.com\n    repository: xxx/worker\n    tag: v2.1.0\n",
+		},
+		{
+			Image{Path: "nginx", ValueKeys: map[string]string{"values.yaml": "proxy.tag"}},
+			"v3.1.0",
+			"proxy:\n  image: nginx\n  tag: v3.1.0\n",
+		},
+		{
+			Image{Path: "bitnami/nginx", ValueKeys: map[string]string{"values.yaml": "hub.image.tag"}},
+			"v4.1.0",
+			"    registry: docker.io\n    repository: bitnami/nginx\n    tag: v4.1.0\n",
+		},
+		{
+			Image{Path: "redis", ValueKeys: map[string]string{"values.yaml": "cache.image.tag"}},
+			"v5.1.0",
+			"    repository: docker.io/library/redis\n    tag: v5.1.0\n",
+		},
+	}
+	for _, image := range images {
+		imageVer, err := version.NewSemanticImageVersion(image.tag)
+		if err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+
+		// the key of another image is not updated
+		for _, other := range images {
+			if other.image.Path == image.image.Path {
+				continue
+			}
+			otherImage := image.image
+			otherImage.ValueKeys = other.image.ValueKeys
+			if _, _, updated, err := u.Update("values.yaml", []byte(values), otherImage, imageVer); err != nil || updated {
+				t.Errorf("%s: expected not updated by %s, got updated %v (%v)", image.image.Path, other.image.ValueKeys["values.yaml"], updated, err)
+			}
+		}
+
+		buf, _, updated, err := u.Update("values.yaml", []byte(values), image.image, imageVer)
+		if err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+		if !updated {
+			t.Fatalf("%s: expected updated, got not updated", image.image.Path)
+		}
+		values = string(buf)
+		if !strings.Contains(values, image.result) {
+			t.Errorf("%s: expected %q in %q", image.image.Path, image.result, values)
+		}
+	}
+}
+
+func TestHelmUpdater_checkValueKeys(t *testing.T) {
+	u := &HelmUpdater{
+		log: log.NullLogger{},
+	}
+	tests := []struct {
+		name   string
+		values string
+		images []Image
+		err    bool
+	}{
+		{
+			"same key without repository",
+			"image:\n  tag: v1.0.0\n",
+			[]Image{{Path: "xxx/app"}, {Path: "xxx/worker"}},
+			true,
+		},
+		{
+			"same key with repository",
+			"image:\n  repository: xxx/app\n  tag: v1.0.0\n",
+			[]Image{{Path: "xxx/app"}, {Path: "xxx/worker"}},
+			false,
+		},
+		{
+			"different keys",
+			"app:\n  tag: v1.0.0\nworker:\n  tag: v1.0.0\n",
+			[]Image{
+				{Path: "xxx/app", ValueKeys: map[string]string{"values.yaml": "app.tag"}},
+				{Path: "xxx/worker", ValueKeys: map[string]string{"values.yaml": "worker.tag"}},
+			},
+			false,
+		},
+		{
+			"same key not in the values",
+			"app:\n  tag: v1.0.0\n",
+			[]Image{{Path: "xxx/app"}, {Path: "xxx/worker"}},
+			false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := u.checkValueKeys("values.yaml", []byte(test.values), test.images)
+			if test.err && err == nil {
+				t.Errorf("expected error, got nil")
+			}
+			if !test.err && err != nil {
+				t.Errorf("got unexpected error: %s", err.Error())
+			}
+		})
+	}
+}
+
+func TestHelmUpdater_UpdateDigestField(t *testing.T) {
+	u := &HelmUpdater{
+		log: log.NullLogger{},
+	}
+	image := Image{DigestMode: DigestModeField}
+	imageVer, err := version.NewSemanticImageVersion("v1.0.0")
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	pinned := version.WithDigest(imageVer, "sha256:1111")
+	values := "# image of the app\nimage:\n  tag: \"v1.0.0\" # pinned\n  pullPolicy: IfNotPresent\n"
+	buf, _, updated, err := u.Update("values.yaml", []byte(values), image, pinned)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if !updated {
+		t.Fatalf("expected updated, got not updated")
+	}
+	expected := "# image of the app\nimage:\n  tag: \"v1.0.0\" # pinned\n  pullPolicy: IfNotPresent\n  digest: sha256:1111\n"
+	if string(buf) != expected {
+		t.Errorf("expected %q, got %q", expected, string(buf))
+	}
+}
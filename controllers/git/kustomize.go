@@ -0,0 +1,106 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/kazylla/gitops-controller/controllers/version"
+	"gopkg.in/yaml.v3"
+)
+
+// KustomizeUpdater updates newTag in imageTags of kustomization.yaml
+type KustomizeUpdater struct {
+	config Config
+	log    logr.Logger
+}
+
+// Update updates newTag of the target image in imageTags
+func (u *KustomizeUpdater) Update(path string, buf []byte, image Image, imageVer version.ImageVersion) ([]byte, string, bool, error) {
+	log := u.log
+	registryTag := imageVer.GetTag()
+	registryDigest := digestOf(imageVer)
+
+	doc, err := parseYAMLDocument(buf)
+	var imageTags *yaml.Node
+	if err == nil && len(doc.roots()) > 0 {
+		imageTags = mappingValue(doc.roots()[0], "imageTags")
+	}
+	if imageTags == nil || imageTags.Kind != yaml.SequenceNode {
+		log.Info("there is no imageTags, ignored", "path", path)
+		return nil, "", false, nil
+	}
+
+	updated := false
+	var oldTag string
+	for _, imageTag := range imageTags.Content {
+		var imageName, imageNewName string
+		if n := mappingValue(imageTag, "name"); n != nil {
+			imageName = n.Value
+		}
+		if n := mappingValue(imageTag, "newName"); n != nil {
+			imageNewName = n.Value
+		}
+		if imageName != image.Path && imageNewName != image.Path {
+			// continue if image name is not target
+			// (kustomize.yaml may have newTags for multiple images)
+			log.V(1).Info("image name is not target", "target", image.Path, "yaml", fmt.Sprintf("%s,%s", imageName, imageNewName))
+			continue
+		}
+
+		// continue if the version on git repository is newer
+		newTag := mappingValue(imageTag, "newTag")
+		digest := mappingValue(imageTag, "digest")
+		var imageNewTag string
+		if newTag != nil {
+			var imageDigest string
+			imageNewTag, imageDigest = version.SplitDigest(newTag.Value)
+			if digest != nil {
+				imageDigest = digest.Value
+			}
+			newer, err := isNewer(imageVer, imageNewTag, imageDigest)
+			if err != nil {
+				return nil, "", false, err
+			}
+			if !newer {
+				log.V(1).Info("this tag is older than current", "current", imageNewTag, "this tag", registryTag)
+				continue
+			}
+		} else {
+			log.Info("since newTag was not found, create it", "newTag", registryTag)
+		}
+
+		// update version
+		if newTag != nil {
+			err = doc.setScalar(newTag, tagWithDigest(imageVer, image.DigestMode))
+		} else {
+			err = doc.addKey(imageTag, "newTag", tagWithDigest(imageVer, image.DigestMode))
+		}
+		if err != nil {
+			return nil, "", false, err
+		}
+		switch {
+		case registryDigest != "" && image.DigestMode == DigestModeField && digest != nil:
+			err = doc.setScalar(digest, registryDigest)
+		case registryDigest != "" && image.DigestMode == DigestModeField:
+			err = doc.addKey(imageTag, "digest", registryDigest)
+		case digest != nil:
+			err = doc.removeKey(imageTag, "digest")
+		}
+		if err != nil {
+			return nil, "", false, err
+		}
+		if !updated {
+			oldTag = imageNewTag
+		}
+		updated = true
+	}
+
+	if !updated {
+		return nil, "", false, nil
+	}
+	writeBuf, err := doc.bytes()
+	if err != nil {
+		return nil, "", false, err
+	}
+	return writeBuf, oldTag, true, nil
+}
@@ -0,0 +1,145 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/kazylla/gitops-controller/controllers/version"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const kustomization = `# overlay for dev
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - ../../base   # shared manifests
+
+imageTags:
+  # the app image
+  - name: xxx/app
+    newTag: "v1.0.0" # bumped by gitops-controller
+  - name: xxx/sidecar
+    newTag: 'v1.10.0'
+`
+
+func TestKustomizeUpdater_Update(t *testing.T) {
+	tests := []struct {
+		name       string
+		imagePath  string
+		digestMode DigestMode
+		tag        string
+		digest     string
+		buf        string
+		oldTag     string
+		result     string
+	}{
+		{
+			"only newTag is replaced",
+			"xxx/app",
+			DigestModeTag,
+			"v1.1.0",
+			"",
+			kustomization,
+			"v1.0.0",
+			`# overlay for dev
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - ../../base   # shared manifests
+
+imageTags:
+  # the app image
+  - name: xxx/app
+    newTag: "v1.1.0" # bumped by gitops-controller
+  - name: xxx/sidecar
+    newTag: 'v1.10.0'
+`,
+		},
+		{
+			"single quoted newTag is replaced",
+			"xxx/sidecar",
+			DigestModeTag,
+			"v1.11.0",
+			"",
+			kustomization,
+			"v1.10.0",
+			`# overlay for dev
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - ../../base   # shared manifests
+
+imageTags:
+  # the app image
+  - name: xxx/app
+    newTag: "v1.0.0" # bumped by gitops-controller
+  - name: xxx/sidecar
+    newTag: 'v1.11.0'
+`,
+		},
+		{
+			"newTag and digest are added",
+			"xxx/app",
+			DigestModeField,
+			"v1.1.0",
+			"sha256:1111",
+			"imageTags:\n- name: xxx/app # no tag yet\n",
+			"",
+			"imageTags:\n- name: xxx/app # no tag yet\n  newTag: v1.1.0\n  digest: sha256:1111\n",
+		},
+		{
+			"digest field is removed for DigestModeTag",
+			"xxx/app",
+			DigestModeTag,
+			"v1.1.0",
+			"sha256:1111",
+			"imageTags:\n- name: xxx/app\n  newTag: v1.0.0\n  digest: sha256:0000\n  newName: xxx/app\n",
+			"v1.0.0",
+			"imageTags:\n- name: xxx/app\n  newTag: v1.1.0@sha256:1111\n  newName: xxx/app\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &KustomizeUpdater{
+				log: log.NullLogger{},
+			}
+			image := Image{Path: test.imagePath, DigestMode: test.digestMode}
+			imageVer, err := version.NewImageVersion(test.tag, version.TagFormatSemantic, version.Options{})
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if test.digest != "" {
+				imageVer = version.WithDigest(imageVer, test.digest)
+			}
+			buf, oldTag, updated, err := u.Update("kustomization.yaml", []byte(test.buf), image, imageVer)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if !updated {
+				t.Fatalf("expected updated, got not updated")
+			}
+			if oldTag != test.oldTag {
+				t.Errorf("expected old tag %q, got %q", test.oldTag, oldTag)
+			}
+			if string(buf) != test.result {
+				t.Errorf("expected %q, got %q", test.result, string(buf))
+			}
+		})
+	}
+}
+
+func TestKustomizeUpdater_UpdateOlder(t *testing.T) {
+	u := &KustomizeUpdater{
+		log: log.NullLogger{},
+	}
+	imageVer, err := version.NewSemanticImageVersion("v0.9.0")
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	_, _, updated, err := u.Update("kustomization.yaml", []byte(kustomization), Image{Path: "xxx/app"}, imageVer)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if updated {
+		t.Errorf("expected not updated, got updated")
+	}
+}
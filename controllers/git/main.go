@@ -4,25 +4,49 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/kazylla/gitops-controller/controllers/version"
 
 	"github.com/go-logr/logr"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/ssh"
 	"gopkg.in/src-d/go-billy.v4/memfs"
 	"gopkg.in/src-d/go-git.v4/storage/memory"
-	"gopkg.in/yaml.v2"
 
 	"gopkg.in/src-d/go-billy.v4"
 	"gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
-	plumbing_http "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+)
+
+type DigestMode int
+
+const (
+	// DigestModeTag writes the digest into newTag as "<tag>@<digest>"
+	DigestModeTag DigestMode = iota
+	// DigestModeField writes the digest into the kustomize digest field
+	DigestModeField
+)
+
+type CommitPolicy int
+
+const (
+	// CommitPolicyLatest commits only the newest version of each image
+	CommitPolicyLatest CommitPolicy = iota
+	// CommitPolicyEvery commits every version of each image from oldest
+	CommitPolicyEvery
 )
 
 type Config struct {
-	ImagePath     string
+	Namespace     string
+	Name          string
+	Updater       UpdaterType
+	CommitPolicy  CommitPolicy
+	Templates     *Templates
 	Repo          string
 	Branch        string
 	ReleaseBranch string
@@ -32,6 +56,32 @@ type Config struct {
 	Log           logr.Logger
 	Username      string
 	Password      string
+	// TokenAuth sends Password as the token to the GitHub API instead of the basic auth of Username and Password
+	TokenAuth bool
+	// GitHubApp authenticates as the GitHub App instead of Username and Password
+	GitHubApp *GitHubApp
+	// SSHKey authenticates the SSH repository URL instead of Username and Password
+	SSHKey *SSHKey
+	// SigningKey signs the commits unless nil
+	SigningKey *SigningKey
+
+	// Provider is the service to create the PR into Branch with the API at APIURL,
+	// with the options PRLabels, PRAssignees and PRRemoveSourceBranch
+	Provider             Provider
+	APIURL               string
+	PRLabels             []string
+	PRAssignees          []string
+	PRRemoveSourceBranch bool
+}
+
+// Image is the image to update and its new versions sorted by ascending
+type Image struct {
+	Name       string
+	Path       string
+	Registry   string
+	DigestMode DigestMode
+	ValueKeys  map[string]string
+	Versions   []version.ImageVersion
 }
 
 type GitRepo struct {
@@ -40,6 +90,18 @@ type GitRepo struct {
 	repo     *git.Repository
 	worktree *git.Worktree
 	remote   *git.Remote
+	auth     transport.AuthMethod
+	updater  Updater
+
+	templates *Templates
+
+	// pgpKey or sshSigner signs the commits
+	pgpKey    *openpgp.Entity
+	sshSigner ssh.Signer
+
+	// paths are the files expanded from config.Paths, and missingPaths are the ones which matched no file
+	paths        []string
+	missingPaths []string
 }
 
 // NewGitRepo clones the specified git repository branch
@@ -59,19 +121,29 @@ func NewGitRepo(c Config) (*GitRepo, error) {
 	}
 	branches = append(branches, c.Branch)
 
-	// clone git repo into inmem storage
+	// push and create PRs with the installation token of the GitHub App
 	var err error
+	if c.GitHubApp != nil {
+		token, err := c.GitHubApp.Token()
+		if err != nil {
+			return nil, err
+		}
+		c.Username, c.Password, c.TokenAuth = gitHubAppUsername, token, true
+	}
+
+	// choose the auth method from the repository URL
+	gitRepo.auth, err = newAuth(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// clone git repo into inmem storage
 	gitRepo.fs = memfs.New()
 	for i, b := range branches {
 		cloneOptions := &git.CloneOptions{
 			URL:           c.Repo,
 			ReferenceName: plumbing.ReferenceName(fmt.Sprintf("refs/heads/%s", b)),
-		}
-		if c.Username != "" && c.Password != "" {
-			cloneOptions.Auth = &plumbing_http.BasicAuth{
-				Username: c.Username,
-				Password: c.Password,
-			}
+			Auth:          gitRepo.auth,
 		}
 		gitRepo.repo, err = git.Clone(memory.NewStorage(), gitRepo.fs, cloneOptions)
 		if err == plumbing.ErrReferenceNotFound && i < len(branches)-1 {
@@ -102,11 +174,45 @@ func NewGitRepo(c Config) (*GitRepo, error) {
 		return nil, err
 	}
 
+	// expand glob patterns and directories
+	gitRepo.paths, gitRepo.missingPaths, err = expandPaths(gitRepo.fs, c.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	// use the default templates unless specified
+	gitRepo.templates = c.Templates
+	if gitRepo.templates == nil {
+		gitRepo.templates, err = NewTemplates("", "", "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// read the signing key
+	if c.SigningKey != nil {
+		switch c.SigningKey.Format {
+		case SigningFormatOpenPGP:
+			gitRepo.pgpKey, err = readOpenPGPKey(*c.SigningKey)
+		case SigningFormatSSH:
+			gitRepo.sshSigner, err = readSSHKey(*c.SigningKey)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	gitRepo.config = c
+	gitRepo.updater = newUpdater(c, c.Log.WithValues("git_repo", c.Repo))
 
 	return gitRepo, nil
 }
 
+// MissingPaths is a getter for getting the paths which matched no file
+func (gitRepo *GitRepo) MissingPaths() []string {
+	return gitRepo.missingPaths
+}
+
 // readFile reads the entire file from the specified path
 func (gitRepo *GitRepo) readFile(path string) ([]byte, error) {
 	file, err := gitRepo.fs.OpenFile(path, os.O_RDONLY, 0644)
@@ -145,107 +251,166 @@ func (gitRepo *GitRepo) updateFile(path string, writeBuf []byte) error {
 	return nil
 }
 
-// CommitTags creates a commit that updates specific image tags
-func (gitRepo *GitRepo) CommitTags(imageVers []version.ImageVersion) (string, error) {
+// CommitTags creates commits that update specific image tags.
+// Each commit updates the images to their next versions from oldest, so that the images are updated together.
+// With CommitPolicyLatest, only the newest versions are committed at once.
+func (gitRepo *GitRepo) CommitTags(images []Image) error {
 	log := gitRepo.config.Log.WithValues("git_repo", gitRepo.config.Repo)
 
-	var latestTag string
-
-	for _, v := range imageVers {
-		registryTag := v.GetTag()
-		latestTag = registryTag
-		updated := false
-
-		log.Info("processing", "tag", registryTag)
-
-		for _, path := range gitRepo.config.Paths {
-
-			log.Info("reading", "path", path)
+	// skip to the newest version of each image unless every version should be committed
+	if gitRepo.config.CommitPolicy == CommitPolicyLatest {
+		latestImages := make([]Image, len(images))
+		for i, image := range images {
+			if n := len(image.Versions); n > 1 {
+				log.Info("skipping older versions", "image", image.Path, "skipped", n-1)
+				image.Versions = image.Versions[n-1:]
+			}
+			latestImages[i] = image
+		}
+		images = latestImages
+	}
 
+	// the images of the same value key in Helm values must be distinguished by the repository
+	if helm, ok := gitRepo.updater.(*HelmUpdater); ok {
+		for _, path := range gitRepo.paths {
 			readBuf, err := gitRepo.readFile(path)
 			if err != nil {
-				return "", err
+				return err
 			}
+			if err := helm.checkValueKeys(path, readBuf, images); err != nil {
+				return err
+			}
+		}
+	}
 
-			m := make(map[interface{}]interface{})
-			err = yaml.Unmarshal(readBuf, &m)
+	for i := 0; ; i++ {
+		remaining := false
+		data := CommitData{
+			Namespace: gitRepo.config.Namespace,
+			Name:      gitRepo.config.Name,
+			Images:    make([]ImageUpdate, 0),
+			Files:     make([]string, 0),
+		}
+		tags := make([]string, 0)
 
-			if _, ok := m["imageTags"]; !ok {
-				log.Info("there is no imageTags, ignored", "path", path)
+		for _, image := range images {
+			if i >= len(image.Versions) {
 				continue
 			}
+			remaining = true
+			v := image.Versions[i]
+			registryTag := v.GetTag()
+			updated := false
+			var oldTag string
 
-			imageTags := m["imageTags"].([]interface{})
-			for _, t := range imageTags {
-				var imageNewName string
-				imageTag := t.(map[interface{}]interface{})
-				imageName := imageTag["name"].(string)
-				if _, ok := imageTag["newName"]; ok {
-					imageNewName = imageTag["newName"].(string)
-				}
-				if imageName != gitRepo.config.ImagePath && imageNewName != gitRepo.config.ImagePath {
-					// continue if image name is not target
-					// (kustomize.yaml may have newTags for multiple images)
-					log.V(1).Info("image name is not target", "target", gitRepo.config.ImagePath, "yaml", fmt.Sprintf("%s,%s", imageName, imageNewName))
-					continue
-				}
+			log.Info("processing", "image", image.Path, "tag", registryTag)
+
+			for _, path := range gitRepo.paths {
 
-				// continue if the version on git repository is newer
-				if _, ok := imageTag["newTag"]; ok {
-					imageNewTag := imageTag["newTag"].(string)
-					result, err := v.Compare(imageNewTag)
-					if err != nil {
-						return "", err
-					}
-					if result <= 0 {
-						log.V(1).Info("this tag is older than current", "current", imageNewTag, "this tag", registryTag)
-						continue
-					}
-				} else {
-					log.Info("since newTag was not found, create it", "newTag", registryTag)
+				log.Info("reading", "path", path)
+
+				readBuf, err := gitRepo.readFile(path)
+				if err != nil {
+					return err
 				}
 
-				// update version
-				imageTag["newTag"] = registryTag
-				writeBuf, err := yaml.Marshal(m)
+				writeBuf, currentTag, ok, err := gitRepo.updater.Update(path, readBuf, image, v)
 				if err != nil {
-					return "", err
+					return err
+				}
+				if !ok {
+					continue
 				}
 				err = gitRepo.updateFile(path, writeBuf)
 				if err != nil {
-					return "", err
+					return err
 				}
 
+				if !updated {
+					oldTag = currentTag
+				}
 				updated = true
+				data.Files = appendUnique(data.Files, path)
 				log.Info("updated", "path", path)
 			}
+
+			if updated {
+				tags = append(tags, registryTag)
+				data.Images = append(data.Images, ImageUpdate{
+					Name:     image.Name,
+					Path:     image.Path,
+					Registry: image.Registry,
+					OldTag:   oldTag,
+					NewTag:   registryTag,
+					Digest:   digestOf(v),
+				})
+			}
 		}
 
-		if !updated {
+		if !remaining {
+			break
+		}
+		if len(tags) == 0 {
 			continue
 		}
 
-		commitLog := fmt.Sprintf("update imageTags to %s for %s by gitops-controller", registryTag, gitRepo.config.ImagePath)
-		hash, prBranch, err := gitRepo.commitAndPush(registryTag, commitLog, gitRepo.config.CommitName, gitRepo.config.CommitEmail)
+		data.Image = data.Images[0]
+
+		commitLog, err := gitRepo.templates.CommitMessage(data)
+		if err != nil {
+			return err
+		}
+		hash, prBranch, err := gitRepo.commitAndPush(strings.Join(tags, "-"), commitLog, gitRepo.config.CommitName, gitRepo.config.CommitEmail)
 		if err != nil {
-			return "", err
+			return err
 		}
 
-		log.Info("new commit created", "tag", registryTag, "hash", hash)
+		log.Info("new commit created", "tags", tags, "hash", hash)
 
+		// the branch is pushed with the SSH key as well, but the PR is created by the API which requires the token
+		if prBranch != "" && gitRepo.config.Password == "" {
+			log.Info("PR is skipped since there is no API token (\"token\" in the secret or GITOPS_GIT_PASSWORD)", "branch", prBranch)
+			continue
+		}
 		if prBranch != "" {
-			pr := NewPR(gitRepo.config.Repo, gitRepo.config.Username, gitRepo.config.Password)
+			pr := NewPR(gitRepo.config.Provider, gitRepo.config.Repo, gitRepo.config.APIURL, gitRepo.config.Username, gitRepo.config.Password)
+			if githubPR, ok := pr.(*GithubPR); ok {
+				githubPR.BasicAuth = !gitRepo.config.TokenAuth
+			}
 			if pr != nil {
-				err = pr.CreatePR(registryTag, prBranch)
+				newPR := PullRequest{
+					Head:               prBranch,
+					Base:               gitRepo.config.Branch,
+					Labels:             gitRepo.config.PRLabels,
+					Assignees:          gitRepo.config.PRAssignees,
+					RemoveSourceBranch: gitRepo.config.PRRemoveSourceBranch,
+				}
+				if newPR.Title, err = gitRepo.templates.PRTitle(data); err != nil {
+					return err
+				}
+				if newPR.Body, err = gitRepo.templates.PRBody(data); err != nil {
+					return err
+				}
+				err = pr.CreatePR(newPR)
 				if err != nil {
-					return "", err
+					return err
 				}
-				log.Info("new PR created", "tag", registryTag, "hash", hash)
+				log.Info("new PR created", "tags", tags, "hash", hash)
 			}
 		}
 	}
 
-	return latestTag, nil
+	return nil
+}
+
+// appendUnique appends the string unless the slice has it
+func appendUnique(s []string, v string) []string {
+	for _, e := range s {
+		if e == v {
+			return s
+		}
+	}
+	return append(s, v)
 }
 
 // commitAndPush creates one commit from the work tree and pushes to remote origin
@@ -256,10 +421,17 @@ func (gitRepo *GitRepo) commitAndPush(tag, commitLog, name, email string) (strin
 			Email: email,
 			When:  time.Now(),
 		},
+		SignKey: gitRepo.pgpKey,
 	})
 	if err != nil {
 		return "", "", err
 	}
+	if gitRepo.sshSigner != nil {
+		commit, err = gitRepo.signCommit(commit)
+		if err != nil {
+			return "", "", err
+		}
+	}
 	err = gitRepo.repo.Storer.SetReference(plumbing.NewReferenceFromStrings(gitRepo.config.ReleaseBranch, commit.String()))
 	if err != nil {
 		return "", "", err
@@ -278,12 +450,7 @@ func (gitRepo *GitRepo) commitAndPush(tag, commitLog, name, email string) (strin
 			RefSpecs: []config.RefSpec{
 				config.RefSpec(plumbing.ReferenceName(fmt.Sprintf("%s:refs/heads/%s", gitRepo.config.ReleaseBranch, b))),
 			},
-		}
-		if gitRepo.config.Username != "" && gitRepo.config.Password != "" {
-			pushOptions.Auth = &plumbing_http.BasicAuth{
-				Username: gitRepo.config.Username,
-				Password: gitRepo.config.Password,
-			}
+			Auth: gitRepo.auth,
 		}
 		err = gitRepo.remote.Push(pushOptions)
 		if err != nil {
@@ -0,0 +1,185 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kazylla/gitops-controller/controllers/version"
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/util"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/storage/memory"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// newRemoteRepo creates a bare repository which has the files in the master branch
+func newRemoteRepo(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "gitops-controller")
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if _, err := git.PlainInit(dir, true); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	fs := memfs.New()
+	repo, err := git.Init(memory.NewStorage(), fs)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	for path, content := range files {
+		if err := util.WriteFile(fs, path, []byte(content), 0644); err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+		if _, err := worktree.Add(path); err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+	}
+	_, err = worktree.Commit("initial commit", &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{dir}}); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if err := repo.Push(&git.PushOptions{Progress: ioutil.Discard}); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	return dir
+}
+
+// commitCount counts the commits in the master branch of the repository
+func commitCount(t *testing.T, dir string) int {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	commits, err := repo.Log(&git.LogOptions{})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	count := 0
+	_ = commits.ForEach(func(*object.Commit) error {
+		count++
+		return nil
+	})
+	return count
+}
+
+func TestGitRepo_CommitTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  CommitPolicy
+		commits int
+	}{
+		{
+			"latest policy commits only the newest tags",
+			CommitPolicyLatest,
+			1,
+		},
+		{
+			"every policy commits every tag",
+			CommitPolicyEvery,
+			2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := newRemoteRepo(t, map[string]string{
+				"kustomization.yaml": "imageTags:\n- name: xxx/api\n  newTag: v1.0.0\n- name: xxx/worker\n  newTag: v1.0.0\n",
+			})
+			defer os.RemoveAll(dir)
+
+			gitRepo, err := NewGitRepo(Config{
+				Repo:         dir,
+				Paths:        []string{"kustomization.yaml"},
+				CommitPolicy: test.policy,
+				CommitName:   "gitops-controller",
+				CommitEmail:  "gitops-controller@example.com",
+				Log:          log.NullLogger{},
+			})
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+
+			images := []Image{
+				{Path: "xxx/api", Versions: semanticVersions(t, "v1.1.0", "v1.2.0")},
+				{Path: "xxx/worker", Versions: semanticVersions(t, "v1.1.0")},
+			}
+			if err := gitRepo.CommitTags(images); err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+
+			// the initial commit and the commits of tags
+			if count := commitCount(t, dir); count != test.commits+1 {
+				t.Errorf("expected %d commits, got %d", test.commits+1, count)
+			}
+			buf, err := gitRepo.readFile("kustomization.yaml")
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			expected := "imageTags:\n- name: xxx/api\n  newTag: v1.2.0\n- name: xxx/worker\n  newTag: v1.1.0\n"
+			if string(buf) != expected {
+				t.Errorf("expected %q, got %q", expected, string(buf))
+			}
+		})
+	}
+}
+
+func TestGitRepo_CommitTags_WithoutToken(t *testing.T) {
+	dir := newRemoteRepo(t, map[string]string{
+		"kustomization.yaml": "imageTags:\n- name: xxx/api\n  newTag: v1.0.0\n",
+	})
+	defer os.RemoveAll(dir)
+
+	gitRepo, err := NewGitRepo(Config{
+		Repo:          dir,
+		ReleaseBranch: "release",
+		Paths:         []string{"kustomization.yaml"},
+		CommitPolicy:  CommitPolicyLatest,
+		CommitName:    "gitops-controller",
+		CommitEmail:   "gitops-controller@example.com",
+		Log:           log.NullLogger{},
+	})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	// the branches are pushed even if the PR is skipped without the token
+	images := []Image{{Path: "xxx/api", Versions: semanticVersions(t, "v1.1.0")}}
+	if err := gitRepo.CommitTags(images); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	for _, branch := range []string{"release", "release-v1.1.0"} {
+		if _, err := repo.Reference(plumbing.NewBranchReferenceName(branch), false); err != nil {
+			t.Errorf("branch %s is not pushed: %s", branch, err.Error())
+		}
+	}
+}
+
+func semanticVersions(t *testing.T, tags ...string) []version.ImageVersion {
+	imageVers := make([]version.ImageVersion, 0, len(tags))
+	for _, tag := range tags {
+		v, err := version.NewSemanticImageVersion(tag)
+		if err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+		imageVers = append(imageVers, v)
+	}
+	return imageVers
+}
@@ -0,0 +1,130 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/kazylla/gitops-controller/controllers/version"
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestUpdater updates the image of containers in the pod templates of plain Kubernetes manifests
+type ManifestUpdater struct {
+	config Config
+	log    logr.Logger
+}
+
+// podSpecPaths are the paths to the pod spec for each kind
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// splitImage splits the image reference into the name, the tag and the digest
+func splitImage(image string) (string, string, string) {
+	name, digest := image, ""
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, digest = name[:i], name[i+1:]
+	}
+	var tag string
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+	return name, tag, digest
+}
+
+// podSpec returns the pod spec of the manifest, or nil if the kind has no pod template
+func podSpec(root *yaml.Node) *yaml.Node {
+	kind := mappingValue(root, "kind")
+	if kind == nil {
+		return nil
+	}
+	paths, ok := podSpecPaths[kind.Value]
+	if !ok {
+		return nil
+	}
+	node := root
+	for _, p := range paths {
+		node = mappingValue(node, p)
+	}
+	return node
+}
+
+// Update updates the tag of the target image in containers and initContainers
+func (u *ManifestUpdater) Update(path string, buf []byte, image Image, imageVer version.ImageVersion) ([]byte, string, bool, error) {
+	log := u.log
+	registryTag := imageVer.GetTag()
+
+	doc, err := parseYAMLDocument(buf)
+	if err != nil {
+		log.Info("unable to parse manifests, ignored", "path", path, "error", err.Error())
+		return nil, "", false, nil
+	}
+
+	// the manifest has no digest field, so that the digest is always written with the tag
+	newTag := tagWithDigest(imageVer, DigestModeTag)
+
+	updated := false
+	var oldTag string
+	for _, root := range doc.roots() {
+		spec := podSpec(root)
+		if spec == nil {
+			continue
+		}
+		for _, key := range []string{"initContainers", "containers"} {
+			containers := mappingValue(spec, key)
+			if containers == nil || containers.Kind != yaml.SequenceNode {
+				continue
+			}
+			for _, container := range containers.Content {
+				imageNode := mappingValue(container, "image")
+				if imageNode == nil || imageNode.Kind != yaml.ScalarNode {
+					continue
+				}
+				name, tag, digest := splitImage(imageNode.Value)
+				if name != image.Path {
+					log.V(1).Info("image name is not target", "target", image.Path, "yaml", name)
+					continue
+				}
+
+				// continue if the version on git repository is newer
+				if tag != "" {
+					newer, err := isNewer(imageVer, tag, digest)
+					if err != nil {
+						return nil, "", false, err
+					}
+					if !newer {
+						log.V(1).Info("this tag is older than current", "current", tag, "this tag", registryTag)
+						continue
+					}
+				} else {
+					log.Info("since tag was not found, create it", "image", imageNode.Value, "tag", registryTag)
+				}
+
+				if err := doc.setScalar(imageNode, fmt.Sprintf("%s:%s", name, newTag)); err != nil {
+					return nil, "", false, err
+				}
+				if !updated {
+					oldTag = tag
+				}
+				updated = true
+			}
+		}
+	}
+
+	if !updated {
+		return nil, "", false, nil
+	}
+	writeBuf, err := doc.bytes()
+	if err != nil {
+		return nil, "", false, err
+	}
+	return writeBuf, oldTag, true, nil
+}
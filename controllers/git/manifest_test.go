@@ -0,0 +1,135 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/kazylla/gitops-controller/controllers/version"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestSplitImage(t *testing.T) {
+	tests := []struct {
+		image  string
+		name   string
+		tag    string
+		digest string
+	}{
+		{"xxx/app", "xxx/app", "", ""},
+		{"xxx/app:v1.0.0", "xxx/app", "v1.0.0", ""},
+		{"localhost:5000/xxx/app", "localhost:5000/xxx/app", "", ""},
+		{"localhost:5000/xxx/app:v1.0.0@sha256:1111", "localhost:5000/xxx/app", "v1.0.0", "sha256:1111"},
+	}
+	for _, test := range tests {
+		t.Run(test.image, func(t *testing.T) {
+			name, tag, digest := splitImage(test.image)
+			if name != test.name || tag != test.tag || digest != test.digest {
+				t.Errorf("expected %s,%s,%s, got %s,%s,%s", test.name, test.tag, test.digest, name, tag, digest)
+			}
+		})
+	}
+}
+
+func TestManifestUpdater_Update(t *testing.T) {
+	manifests := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: xxx/app:v1.0.0 # same image as app
+      containers:
+        - name: app
+          image: "xxx/app:v1.0.0"
+        - name: sidecar
+          image: xxx/sidecar:v1.0.0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  image: xxx/app:v1.0.0
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: batch
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: batch
+              image: xxx/app
+`
+	expected := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: migrate
+          image: xxx/app:v1.1.0 # same image as app
+      containers:
+        - name: app
+          image: "xxx/app:v1.1.0"
+        - name: sidecar
+          image: xxx/sidecar:v1.0.0
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  image: xxx/app:v1.0.0
+---
+apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: batch
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: batch
+              image: xxx/app:v1.1.0
+`
+
+	u := &ManifestUpdater{
+		log: log.NullLogger{},
+	}
+	image := Image{Path: "xxx/app"}
+	imageVer, err := version.NewSemanticImageVersion("v1.1.0")
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	buf, _, updated, err := u.Update("manifests.yaml", []byte(manifests), image, imageVer)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if !updated {
+		t.Fatalf("expected updated, got not updated")
+	}
+	if string(buf) != expected {
+		t.Errorf("expected %q, got %q", expected, string(buf))
+	}
+
+	// the version on git repository is newer
+	older, err := version.NewSemanticImageVersion("v0.9.0")
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if _, _, updated, err := u.Update("manifests.yaml", []byte(expected), image, older); err != nil || updated {
+		t.Errorf("expected not updated, got updated %v, error %v", updated, err)
+	}
+}
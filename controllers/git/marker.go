@@ -0,0 +1,141 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/kazylla/gitops-controller/controllers/version"
+)
+
+var (
+	// markerRegexp matches the marker comment such as `# {"$gitops": "<namespace>:<name>"}` (full image),
+	// `# {"$gitops": "<namespace>:<name>:tag"}` (tag only) or `# {"$gitops": "<namespace>:<name>:<image name>:tag"}`
+	markerRegexp = regexp.MustCompile(`\{\s*"\$gitops"\s*:\s*"([^"]+)"\s*\}`)
+	// tagRegexp matches the tag which may be pinned by the digest
+	tagRegexp = regexp.MustCompile(`[A-Za-z0-9_][A-Za-z0-9_.-]*(?:@[A-Za-z0-9]+:[0-9a-fA-F]+)?`)
+)
+
+// MarkerUpdater updates the tag or the image on the lines which have the marker comment of the GitOps resource,
+// whatever the file type is
+type MarkerUpdater struct {
+	config Config
+	log    logr.Logger
+}
+
+// parseMarker parses the marker value, and returns the image name and the mode ("image" or "tag")
+// if the marker is for the GitOps resource
+func (u *MarkerUpdater) parseMarker(marker string) (string, string, bool) {
+	parts := strings.Split(marker, ":")
+	if len(parts) < 2 || parts[0] != u.config.Namespace || parts[1] != u.config.Name {
+		return "", "", false
+	}
+	parts = parts[2:]
+	mode := "image"
+	if len(parts) > 0 && (parts[len(parts)-1] == "image" || parts[len(parts)-1] == "tag") {
+		mode = parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+	switch len(parts) {
+	case 0:
+		return "", mode, true
+	case 1:
+		return parts[0], mode, true
+	}
+	return "", "", false
+}
+
+// Update updates the value just before the marker comment
+func (u *MarkerUpdater) Update(path string, buf []byte, image Image, imageVer version.ImageVersion) ([]byte, string, bool, error) {
+	log := u.log
+	registryTag := imageVer.GetTag()
+	newTag := tagWithDigest(imageVer, DigestModeTag)
+
+	imageRegexp := regexp.MustCompile(regexp.QuoteMeta(image.Path) +
+		`(?::([A-Za-z0-9_][A-Za-z0-9_.-]*))?(?:@([A-Za-z0-9]+:[0-9a-fA-F]+))?`)
+
+	found := false
+	updated := false
+	var oldTag string
+	lines := bytes.SplitAfter(buf, []byte("\n"))
+	for i, line := range lines {
+		marker := markerRegexp.FindSubmatchIndex(line)
+		if marker == nil {
+			continue
+		}
+		prefix := line[:marker[0]]
+
+		// find the value to update
+		var start, end int
+		var currentTag, currentDigest, value string
+		imageName, mode, ok := u.parseMarker(string(line[marker[2]:marker[3]]))
+		switch {
+		case !ok:
+			// the marker of another GitOps resource
+			continue
+		case mode == "image" && (imageName == "" || imageName == image.Name):
+			matches := imageRegexp.FindAllSubmatchIndex(prefix, -1)
+			if len(matches) == 0 {
+				log.Info("there is no image before the marker, ignored", "path", path, "line", i+1)
+				continue
+			}
+			m := matches[len(matches)-1]
+			start, end = m[0], m[1]
+			if m[2] >= 0 {
+				currentTag = string(prefix[m[2]:m[3]])
+			}
+			if m[4] >= 0 {
+				currentDigest = string(prefix[m[4]:m[5]])
+			}
+			value = fmt.Sprintf("%s:%s", image.Path, newTag)
+		case mode == "tag" && imageName == image.Name:
+			matches := tagRegexp.FindAllIndex(prefix, -1)
+			if len(matches) == 0 {
+				log.Info("there is no tag before the marker, ignored", "path", path, "line", i+1)
+				continue
+			}
+			m := matches[len(matches)-1]
+			start, end = m[0], m[1]
+			currentTag, currentDigest = version.SplitDigest(string(prefix[start:end]))
+			value = newTag
+		default:
+			// the marker of another image
+			continue
+		}
+		found = true
+
+		// continue if the version on git repository is newer
+		if currentTag != "" {
+			newer, err := isNewer(imageVer, currentTag, currentDigest)
+			if err != nil {
+				return nil, "", false, err
+			}
+			if !newer {
+				log.V(1).Info("this tag is older than current", "current", currentTag, "this tag", registryTag)
+				continue
+			}
+		} else {
+			log.Info("since tag was not found, create it", "line", i+1, "tag", registryTag)
+		}
+
+		newLine := make([]byte, 0, len(line)+len(value))
+		newLine = append(newLine, line[:start]...)
+		newLine = append(newLine, value...)
+		newLine = append(newLine, line[end:]...)
+		lines[i] = newLine
+		if !updated {
+			oldTag = currentTag
+		}
+		updated = true
+	}
+
+	if !found {
+		log.Info("there is no marker, ignored", "path", path)
+	}
+	if !updated {
+		return nil, "", false, nil
+	}
+	return bytes.Join(lines, nil), oldTag, true, nil
+}
@@ -0,0 +1,100 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/kazylla/gitops-controller/controllers/version"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestMarkerUpdater_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     string
+		updated bool
+		result  string
+	}{
+		{
+			"yaml image",
+			"image: xxx/app:v1.0.0 # {\"$gitops\": \"default:app\"}\n",
+			true,
+			"image: xxx/app:v1.1.0 # {\"$gitops\": \"default:app\"}\n",
+		},
+		{
+			"yaml image without tag",
+			"image: xxx/app # {\"$gitops\": \"default:app:image\"}\n",
+			true,
+			"image: xxx/app:v1.1.0 # {\"$gitops\": \"default:app:image\"}\n",
+		},
+		{
+			"jsonnet tag",
+			"{\n  tag: 'v1.0.0', // {\"$gitops\": \"default:app:api:tag\"}\n}\n",
+			true,
+			"{\n  tag: 'v1.1.0', // {\"$gitops\": \"default:app:api:tag\"}\n}\n",
+		},
+		{
+			"hcl tag",
+			"image_tag = \"v1.0.0\" # {\"$gitops\": \"default:app:api:tag\"}\n",
+			true,
+			"image_tag = \"v1.1.0\" # {\"$gitops\": \"default:app:api:tag\"}\n",
+		},
+		{
+			".env tag without trailing newline",
+			"APP_NAME=app\nAPP_TAG=v1.0.0 # {\"$gitops\": \"default:app:api:tag\"}",
+			true,
+			"APP_NAME=app\nAPP_TAG=v1.1.0 # {\"$gitops\": \"default:app:api:tag\"}",
+		},
+		{
+			"named image tag",
+			"API_TAG=v1.0.0 # {\"$gitops\": \"default:app:api:tag\"}\nWORKER_TAG=v1.0.0 # {\"$gitops\": \"default:app:worker:tag\"}\n",
+			true,
+			"API_TAG=v1.1.0 # {\"$gitops\": \"default:app:api:tag\"}\nWORKER_TAG=v1.0.0 # {\"$gitops\": \"default:app:worker:tag\"}\n",
+		},
+		{
+			"tag marker of another image",
+			"APP_TAG=v1.0.0 # {\"$gitops\": \"default:app:tag\"}\n",
+			false,
+			"",
+		},
+		{
+			"marker of another resource",
+			"image: xxx/app:v1.0.0 # {\"$gitops\": \"default:other\"}\n",
+			false,
+			"",
+		},
+		{
+			"newer tag on git repository",
+			"image: xxx/app:v1.2.0 # {\"$gitops\": \"default:app\"}\n",
+			false,
+			"",
+		},
+		{
+			"no marker",
+			"image: xxx/app:v1.0.0\n",
+			false,
+			"",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			u := &MarkerUpdater{
+				config: Config{Namespace: "default", Name: "app"},
+				log:    log.NullLogger{},
+			}
+			imageVer, err := version.NewSemanticImageVersion("v1.1.0")
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			buf, _, updated, err := u.Update("file", []byte(test.buf), Image{Name: "api", Path: "xxx/app"}, imageVer)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if updated != test.updated {
+				t.Fatalf("expected updated %v, got %v", test.updated, updated)
+			}
+			if updated && string(buf) != test.result {
+				t.Errorf("expected %q, got %q", test.result, string(buf))
+			}
+		})
+	}
+}
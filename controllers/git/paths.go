@@ -0,0 +1,134 @@
+package git
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/src-d/go-billy.v4"
+)
+
+// cleanPath converts the path in git_paths into the slash-separated path relative to the repository root
+func cleanPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// hasMeta reports whether the path contains any of the glob meta characters
+func hasMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[\`)
+}
+
+// matchPath matches the slash-separated path against the glob pattern,
+// in which "**" matches zero or more directories
+func matchPath(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(patterns, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for i := 0; i <= len(names); i++ {
+				if matchSegments(patterns[1:], names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(names) == 0 {
+			return false
+		}
+		if ok, err := path.Match(patterns[0], names[0]); err != nil || !ok {
+			return false
+		}
+		patterns, names = patterns[1:], names[1:]
+	}
+	return len(names) == 0
+}
+
+// walkFiles lists all files under the directory recursively
+func walkFiles(fs billy.Filesystem, dir string) ([]string, error) {
+	infos, err := fs.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+	files := make([]string, 0)
+	for _, info := range infos {
+		p := path.Join(dir, info.Name())
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				continue
+			}
+			children, err := walkFiles(fs, p)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, children...)
+			continue
+		}
+		files = append(files, p)
+	}
+	return files, nil
+}
+
+// expandPaths expands the glob patterns and the directories in the paths into the files.
+// It also returns the paths which matched no file.
+func expandPaths(fs billy.Filesystem, paths []string) ([]string, []string, error) {
+	expanded := make([]string, 0)
+	missing := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(p string) {
+		if !seen[p] {
+			seen[p] = true
+			expanded = append(expanded, p)
+		}
+	}
+
+	var all []string
+	for _, p := range paths {
+		pattern := cleanPath(p)
+
+		if !hasMeta(pattern) {
+			info, err := fs.Stat(pattern)
+			switch {
+			case err != nil:
+				missing = append(missing, p)
+			case info.IsDir():
+				files, err := walkFiles(fs, pattern)
+				if err != nil {
+					return nil, nil, err
+				}
+				if len(files) == 0 {
+					missing = append(missing, p)
+				}
+				for _, f := range files {
+					add(f)
+				}
+			default:
+				add(pattern)
+			}
+			continue
+		}
+
+		if all == nil {
+			var err error
+			all, err = walkFiles(fs, "")
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		matched := false
+		for _, f := range all {
+			if matchPath(pattern, f) {
+				add(f)
+				matched = true
+			}
+		}
+		if !matched {
+			missing = append(missing, p)
+		}
+	}
+	return expanded, missing, nil
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/util"
+)
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		result  bool
+	}{
+		{"overlays/*/kustomization.yaml", "overlays/dev/kustomization.yaml", true},
+		{"overlays/*/kustomization.yaml", "overlays/dev/app/kustomization.yaml", false},
+		{"**/values-*.yaml", "values-dev.yaml", true},
+		{"**/values-*.yaml", "charts/app/values-dev.yaml", true},
+		{"**/values-*.yaml", "charts/app/values.yaml", false},
+		{"charts/**", "charts/app/values.yaml", true},
+		{"charts/**/values.yaml", "charts/values.yaml", true},
+	}
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.name, func(t *testing.T) {
+			if result := matchPath(test.pattern, test.name); result != test.result {
+				t.Errorf("expected %v, got %v", test.result, result)
+			}
+		})
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	fs := memfs.New()
+	for _, f := range []string{
+		"overlays/dev/kustomization.yaml",
+		"overlays/prd/kustomization.yaml",
+		"charts/app/values.yaml",
+		"charts/app/values-dev.yaml",
+		"manifests/app/deployment.yaml",
+		"manifests/app/cronjob.yaml",
+	} {
+		if err := util.WriteFile(fs, f, []byte{}, 0644); err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+	}
+
+	paths, missing, err := expandPaths(fs, []string{
+		"overlays/*/kustomization.yaml",
+		"**/values-*.yaml",
+		"manifests/app",
+		"./charts/app/values.yaml",
+		"overlays/dev/kustomization.yaml",
+		"overlays/stg/kustomization.yaml",
+		"**/Chart.yaml",
+	})
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	expected := []string{
+		"overlays/dev/kustomization.yaml",
+		"overlays/prd/kustomization.yaml",
+		"charts/app/values-dev.yaml",
+		"manifests/app/cronjob.yaml",
+		"manifests/app/deployment.yaml",
+		"charts/app/values.yaml",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("expected %v, got %v", expected, paths)
+	}
+	expectedMissing := []string{"overlays/stg/kustomization.yaml", "**/Chart.yaml"}
+	if !reflect.DeepEqual(missing, expectedMissing) {
+		t.Errorf("expected %v, got %v", expectedMissing, missing)
+	}
+}
@@ -1,23 +1,75 @@
 package git
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"net/http"
 	"regexp"
 	"strings"
 )
 
+type Provider int
+
+const (
+	// ProviderAuto detects the provider from the host of the repository URL
+	ProviderAuto Provider = iota
+	ProviderGithub
+	ProviderGitlab
+	ProviderBitbucket
+	ProviderBitbucketServer
+	// ProviderGitea is also for Forgejo
+	ProviderGitea
+)
+
+// PullRequest is the pull request to create from Head branch into Base branch
+type PullRequest struct {
+	Title  string
+	Body   string
+	Head   string
+	Base   string
+	Labels []string
+	// Assignees are the usernames to assign, which are the reviewers for Bitbucket Server
+	Assignees []string
+	// RemoveSourceBranch removes Head branch after merged (GitLab and Bitbucket Cloud)
+	RemoveSourceBranch bool
+}
+
 type PR interface {
-	CreatePR(tag, branch string) error
+	CreatePR(pr PullRequest) error
 }
 
-func NewPR(repo, username, password string) PR {
+// NewPR creates the PR of the provider. apiURL is the base URL of the API for self-hosted services,
+// which is derived from the repository URL by default.
+func NewPR(provider Provider, repo, apiURL, username, password string) PR {
 	repoURL, err := parseRepoURL(repo)
 	if err != nil {
 		return nil
 	}
-	switch repoURL.Host {
-	case "github.com":
-		return NewGithubPR(repo, username, password)
+	if provider == ProviderAuto {
+		switch {
+		case repoURL.Host == "github.com":
+			provider = ProviderGithub
+		case repoURL.Host == "gitlab.com" || strings.HasPrefix(repoURL.Host, "gitlab."):
+			provider = ProviderGitlab
+		case repoURL.Host == "bitbucket.org":
+			provider = ProviderBitbucket
+		case repoURL.Host == "gitea.com" || repoURL.Host == "codeberg.org" || strings.HasPrefix(repoURL.Host, "gitea."):
+			provider = ProviderGitea
+		}
+	}
+	switch provider {
+	case ProviderGithub:
+		return NewGithubPR(repo, apiURL, username, password)
+	case ProviderGitlab:
+		return NewGitlabPR(repo, apiURL, username, password)
+	case ProviderBitbucket:
+		return NewBitbucketPR(repo, apiURL, username, password)
+	case ProviderBitbucketServer:
+		return NewBitbucketServerPR(repo, apiURL, username, password)
+	case ProviderGitea:
+		return NewGiteaPR(repo, apiURL, username, password)
 	default:
 		return nil
 	}
@@ -29,17 +81,37 @@ type RepoURL struct {
 	RepoName string
 }
 
-// parseRepoURL parses GitHub repository repoURL string for https or git protocol
+// parseRepoURL parses repository repoURL string for https, ssh or git protocol.
+// The owner may have nested groups such as "group/subgroup" of GitLab.
 func parseRepoURL(repo string) (*RepoURL, error) {
 	repoURL := &RepoURL{}
 
+	var path string
 	switch {
 	case strings.HasPrefix(repo, "https://"):
-		parts := strings.Split(repo, "/")
-		if len(parts) == 5 {
-			repoURL.Host = parts[2]
-			repoURL.Owner = parts[3]
-			repoURL.RepoName = parts[4]
+		// https://[user@]github.com/owner/repo.git
+		parts := strings.SplitN(strings.TrimPrefix(repo, "https://"), "/", 2)
+		if len(parts) == 2 {
+			host := parts[0]
+			if i := strings.LastIndex(host, "@"); i >= 0 {
+				host = host[i+1:]
+			}
+			repoURL.Host = host
+			path = parts[1]
+		}
+	case strings.HasPrefix(repo, "ssh://"):
+		// ssh://git@github.com[:22]/owner/repo.git
+		parts := strings.SplitN(strings.TrimPrefix(repo, "ssh://"), "/", 2)
+		if len(parts) == 2 {
+			host := parts[0]
+			if i := strings.LastIndex(host, "@"); i >= 0 {
+				host = host[i+1:]
+			}
+			if i := strings.LastIndex(host, ":"); i >= 0 {
+				host = host[:i]
+			}
+			repoURL.Host = host
+			path = parts[1]
 		}
 	case strings.HasPrefix(repo, "git@"):
 		parts := strings.Split(repo, ":")
@@ -48,14 +120,15 @@ func parseRepoURL(repo string) (*RepoURL, error) {
 			if len(parts1) == 2 {
 				repoURL.Host = parts1[1]
 			}
-			parts2 := strings.Split(parts[1], "/")
-			if len(parts2) == 2 {
-				repoURL.Owner = parts2[0]
-				repoURL.RepoName = parts2[1]
-			}
+			path = parts[1]
 		}
 	}
 
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		repoURL.Owner = path[:i]
+		repoURL.RepoName = path[i+1:]
+	}
+
 	if repoURL.Host == "" || repoURL.Owner == "" || repoURL.RepoName == "" || !strings.HasSuffix(repoURL.RepoName, ".git") {
 		return nil, fmt.Errorf("invalid repoURL string")
 	}
@@ -65,3 +138,50 @@ func parseRepoURL(repo string) (*RepoURL, error) {
 
 	return repoURL, nil
 }
+
+// apiError is the error response of the API
+type apiError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s %s failed: %s: %s", e.Method, e.URL, e.Status, e.Body)
+}
+
+// doJSON sends the request with the JSON body, and decodes the JSON response into out
+func doJSON(method, url string, setAuth func(req *http.Request), in, out interface{}) error {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, url, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	setAuth(req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &apiError{Method: method, URL: url, StatusCode: resp.StatusCode, Status: resp.Status, Body: string(buf)}
+	}
+	if out != nil {
+		return json.Unmarshal(buf, out)
+	}
+	return nil
+}
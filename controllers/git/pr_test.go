@@ -0,0 +1,69 @@
+package git
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseRepoURL(t *testing.T) {
+	tests := []struct {
+		repo   string
+		result *RepoURL
+	}{
+		{"https://github.com/kazylla/gitops.git", &RepoURL{Host: "github.com", Owner: "kazylla", RepoName: "gitops"}},
+		{"git@github.com:kazylla/gitops.git", &RepoURL{Host: "github.com", Owner: "kazylla", RepoName: "gitops"}},
+		{"ssh://git@github.com/kazylla/gitops.git", &RepoURL{Host: "github.com", Owner: "kazylla", RepoName: "gitops"}},
+		{"ssh://git@github.com:22/kazylla/gitops.git", &RepoURL{Host: "github.com", Owner: "kazylla", RepoName: "gitops"}},
+		{"https://user@bitbucket.org/kazylla/gitops.git", &RepoURL{Host: "bitbucket.org", Owner: "kazylla", RepoName: "gitops"}},
+		{"https://gitlab.example.com/group/subgroup/gitops.git", &RepoURL{Host: "gitlab.example.com", Owner: "group/subgroup", RepoName: "gitops"}},
+		{"git@gitlab.example.com:group/subgroup/gitops.git", &RepoURL{Host: "gitlab.example.com", Owner: "group/subgroup", RepoName: "gitops"}},
+		{"https://github.com/kazylla/gitops", nil},
+		{"https://github.com/gitops.git", nil},
+		{"ftp://github.com/kazylla/gitops.git", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.repo, func(t *testing.T) {
+			result, err := parseRepoURL(test.repo)
+			if test.result == nil {
+				if err == nil {
+					t.Errorf("expected error, got %v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("expected %v, got %v", test.result, result)
+			}
+		})
+	}
+}
+
+func TestNewPR(t *testing.T) {
+	tests := []struct {
+		provider Provider
+		repo     string
+		result   string
+	}{
+		{ProviderAuto, "https://github.com/kazylla/gitops.git", "*git.GithubPR"},
+		{ProviderAuto, "https://gitlab.com/kazylla/gitops.git", "*git.GitlabPR"},
+		{ProviderAuto, "git@gitlab.example.com:group/gitops.git", "*git.GitlabPR"},
+		{ProviderAuto, "https://bitbucket.org/kazylla/gitops.git", "*git.BitbucketPR"},
+		{ProviderAuto, "https://codeberg.org/kazylla/gitops.git", "*git.GiteaPR"},
+		{ProviderAuto, "https://git.example.com/kazylla/gitops.git", "<nil>"},
+		{ProviderGithub, "https://github.example.com/kazylla/gitops.git", "*git.GithubPR"},
+		{ProviderGitea, "https://git.example.com/kazylla/gitops.git", "*git.GiteaPR"},
+		{ProviderGitlab, "https://git.example.com/kazylla/gitops.git", "*git.GitlabPR"},
+		{ProviderBitbucketServer, "https://git.example.com/scm/proj/gitops.git", "*git.BitbucketServerPR"},
+	}
+	for _, test := range tests {
+		t.Run(test.repo, func(t *testing.T) {
+			pr := NewPR(test.provider, test.repo, "", "user", "token")
+			if result := fmt.Sprintf("%T", pr); result != test.result {
+				t.Errorf("expected %s, got %s", test.result, result)
+			}
+		})
+	}
+}
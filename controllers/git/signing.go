@@ -0,0 +1,149 @@
+package git
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+type SigningFormat int
+
+const (
+	// SigningFormatOpenPGP signs the commits with an armored OpenPGP private key
+	SigningFormatOpenPGP SigningFormat = iota
+	// SigningFormatSSH signs the commits with an SSH private key in the sshsig format
+	SigningFormatSSH
+)
+
+// SigningKey is the private key to sign the commits
+type SigningKey struct {
+	Format     SigningFormat
+	PrivateKey []byte
+	Passphrase []byte
+}
+
+// sshSigNamespace is the namespace of the SSH signature which git verifies for the commits
+const sshSigNamespace = "git"
+
+// readOpenPGPKey reads the first entity which has the private key, and decrypts it with the passphrase
+func readOpenPGPKey(key SigningKey) (*openpgp.Entity, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			continue
+		}
+		privateKeys := []*packet.PrivateKey{entity.PrivateKey}
+		for _, subkey := range entity.Subkeys {
+			if subkey.PrivateKey != nil {
+				privateKeys = append(privateKeys, subkey.PrivateKey)
+			}
+		}
+		for _, privateKey := range privateKeys {
+			if !privateKey.Encrypted {
+				continue
+			}
+			if len(key.Passphrase) == 0 {
+				return nil, errors.New("the OpenPGP private key is encrypted, but no passphrase is given")
+			}
+			if err := privateKey.Decrypt(key.Passphrase); err != nil {
+				return nil, err
+			}
+		}
+		return entity, nil
+	}
+	return nil, errors.New("no OpenPGP private key found")
+}
+
+// readSSHKey reads the SSH private key, which is decrypted with the passphrase if given
+func readSSHKey(key SigningKey) (ssh.Signer, error) {
+	if len(key.Passphrase) > 0 {
+		return ssh.ParsePrivateKeyWithPassphrase(key.PrivateKey, key.Passphrase)
+	}
+	return ssh.ParsePrivateKey(key.PrivateKey)
+}
+
+// sshSign creates the armored SSH signature of the message in the sshsig format
+// (https://github.com/openssh/openssh-portable/blob/master/PROTOCOL.sshsig)
+func sshSign(signer ssh.Signer, message []byte) (string, error) {
+	hash := sha512.Sum512(message)
+	signedData := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{sshSigNamespace, "", "sha512", hash[:]})...)
+
+	var signature *ssh.Signature
+	var err error
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		// ssh-rsa (SHA-1) signatures are rejected by git
+		signature, err = algorithmSigner.SignWithAlgorithm(rand.Reader, signedData, ssh.SigAlgoRSASHA2512)
+	} else {
+		signature, err = signer.Sign(rand.Reader, signedData)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	blob := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{1, signer.PublicKey().Marshal(), sshSigNamespace, "", "sha512", ssh.Marshal(signature)})...)
+
+	// wrap the base64 blob at 70 columns like ssh-keygen
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	lines := []string{"-----BEGIN SSH SIGNATURE-----"}
+	for len(encoded) > 70 {
+		lines = append(lines, encoded[:70])
+		encoded = encoded[70:]
+	}
+	lines = append(lines, encoded, "-----END SSH SIGNATURE-----")
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// signCommit stores the copy of the commit signed by the SSH key, and returns its hash
+func (gitRepo *GitRepo) signCommit(hash plumbing.Hash) (plumbing.Hash, error) {
+	commit, err := gitRepo.repo.CommitObject(hash)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	unsigned := &plumbing.MemoryObject{}
+	if err := commit.EncodeWithoutSignature(unsigned); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	reader, err := unsigned.Reader()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	message, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	commit.PGPSignature, err = sshSign(gitRepo.sshSigner, message)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	signed := gitRepo.repo.Storer.NewEncodedObject()
+	if err := commit.Encode(signed); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return gitRepo.repo.Storer.SetEncodedObject(signed)
+}
@@ -0,0 +1,200 @@
+package git
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"golang.org/x/crypto/ssh"
+	"gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// newOpenPGPKey generates the armored private key and public key
+func newOpenPGPKey(t *testing.T) ([]byte, string) {
+	entity, err := openpgp.NewEntity("gitops-controller", "", "gitops-controller@example.com", nil)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	var privateKey, publicKey bytes.Buffer
+	w, err := armor.Encode(&privateKey, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	w.Close()
+	w, err = armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	w.Close()
+	return privateKey.Bytes(), publicKey.String()
+}
+
+// newSSHKey generates the PEM encoded RSA private key
+func newSSHKey(t *testing.T) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// verifySSHSignature verifies the armored sshsig signature of the message
+func verifySSHSignature(t *testing.T, armored string, message []byte, publicKey ssh.PublicKey) {
+	lines := strings.Split(strings.TrimSpace(armored), "\n")
+	if lines[0] != "-----BEGIN SSH SIGNATURE-----" || lines[len(lines)-1] != "-----END SSH SIGNATURE-----" {
+		t.Fatalf("unexpected armor: %q", armored)
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.Join(lines[1:len(lines)-1], ""))
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if !bytes.HasPrefix(blob, []byte("SSHSIG")) {
+		t.Fatalf("unexpected magic: %q", blob[:6])
+	}
+	var sig struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}
+	if err := ssh.Unmarshal(blob[6:], &sig); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if sig.Namespace != "git" || sig.HashAlgorithm != "sha512" {
+		t.Errorf("unexpected namespace %s or hash algorithm %s", sig.Namespace, sig.HashAlgorithm)
+	}
+	if !bytes.Equal(sig.PublicKey, publicKey.Marshal()) {
+		t.Errorf("unexpected public key")
+	}
+	var signature ssh.Signature
+	if err := ssh.Unmarshal(sig.Signature, &signature); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if signature.Format != ssh.SigAlgoRSASHA2512 {
+		t.Errorf("expected %s, got %s", ssh.SigAlgoRSASHA2512, signature.Format)
+	}
+	hash := sha512.Sum512(message)
+	signedData := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{"git", "", "sha512", hash[:]})...)
+	if err := publicKey.Verify(signedData, &signature); err != nil {
+		t.Errorf("got unexpected error: %s", err.Error())
+	}
+}
+
+// headCommit gets the latest commit in the master branch of the repository
+func headCommit(t *testing.T, dir string) *object.Commit {
+	repo, err := git.PlainOpen(dir)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName("master"), true)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	return commit
+}
+
+func TestGitRepo_CommitTags_Signing(t *testing.T) {
+	pgpPrivateKey, pgpPublicKey := newOpenPGPKey(t)
+	sshPrivateKey := newSSHKey(t)
+
+	tests := []struct {
+		name string
+		key  SigningKey
+	}{
+		{
+			"OpenPGP",
+			SigningKey{Format: SigningFormatOpenPGP, PrivateKey: pgpPrivateKey},
+		},
+		{
+			"SSH",
+			SigningKey{Format: SigningFormatSSH, PrivateKey: sshPrivateKey},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := newRemoteRepo(t, map[string]string{
+				"kustomization.yaml": "imageTags:\n- name: xxx/api\n  newTag: v1.0.0\n",
+			})
+			defer os.RemoveAll(dir)
+
+			key := test.key
+			gitRepo, err := NewGitRepo(Config{
+				Repo:        dir,
+				Paths:       []string{"kustomization.yaml"},
+				CommitName:  "gitops-controller",
+				CommitEmail: "gitops-controller@example.com",
+				Log:         log.NullLogger{},
+				SigningKey:  &key,
+			})
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if err := gitRepo.CommitTags([]Image{{Path: "xxx/api", Versions: semanticVersions(t, "v1.1.0")}}); err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+
+			commit := headCommit(t, dir)
+			if commit.Message != "update imageTags to v1.1.0 for xxx/api by gitops-controller" {
+				t.Errorf("unexpected commit message: %q", commit.Message)
+			}
+			switch test.key.Format {
+			case SigningFormatOpenPGP:
+				if _, err := commit.Verify(pgpPublicKey); err != nil {
+					t.Errorf("got unexpected error: %s", err.Error())
+				}
+			case SigningFormatSSH:
+				unsigned := &plumbing.MemoryObject{}
+				if err := commit.EncodeWithoutSignature(unsigned); err != nil {
+					t.Fatalf("got unexpected error: %s", err.Error())
+				}
+				reader, err := unsigned.Reader()
+				if err != nil {
+					t.Fatalf("got unexpected error: %s", err.Error())
+				}
+				message, err := ioutil.ReadAll(reader)
+				if err != nil {
+					t.Fatalf("got unexpected error: %s", err.Error())
+				}
+				signer, err := ssh.ParsePrivateKey(sshPrivateKey)
+				if err != nil {
+					t.Fatalf("got unexpected error: %s", err.Error())
+				}
+				verifySSHSignature(t, commit.PGPSignature, message, signer.PublicKey())
+			}
+		})
+	}
+
+	if _, err := readOpenPGPKey(SigningKey{PrivateKey: []byte("invalid")}); err == nil {
+		t.Errorf("expected error for invalid key, got nil")
+	}
+}
@@ -0,0 +1,96 @@
+package git
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+const (
+	defaultCommitMessageTemplate = `update imageTags to {{ range $i, $image := .Images }}{{ if $i }}, {{ end }}{{ $image.NewTag }} for {{ $image.Path }}{{ end }} by gitops-controller`
+	defaultPRTitleTemplate       = `Release Candidate: {{ .Tags }}`
+	defaultPRBodyTemplate        = `If you want to deploy version {{ .Tags }}, please merge this PR`
+)
+
+// ImageUpdate is the image updated by the commit
+type ImageUpdate struct {
+	Name     string
+	Path     string
+	Registry string
+	OldTag   string
+	NewTag   string
+	Digest   string
+}
+
+// CommitData is the data for the templates of the commit message, the PR title and the PR body
+type CommitData struct {
+	Namespace string
+	Name      string
+	// Image is the first image of Images, for the resource which has a single image
+	Image  ImageUpdate
+	Images []ImageUpdate
+	Files  []string
+}
+
+// Tags returns the new tags of the images joined with commas
+func (d CommitData) Tags() string {
+	tags := make([]string, 0, len(d.Images))
+	for _, image := range d.Images {
+		tags = append(tags, image.NewTag)
+	}
+	return strings.Join(tags, ", ")
+}
+
+// Templates are the templates of the commit message, the PR title and the PR body
+type Templates struct {
+	commitMessage *template.Template
+	prTitle       *template.Template
+	prBody        *template.Template
+}
+
+// NewTemplates parses the text/template strings. The empty string uses the default template.
+func NewTemplates(commitMessage, prTitle, prBody string) (*Templates, error) {
+	parse := func(name, text, defaultText string) (*template.Template, error) {
+		if text == "" {
+			text = defaultText
+		}
+		return template.New(name).Option("missingkey=error").Parse(text)
+	}
+
+	var err error
+	t := &Templates{}
+	if t.commitMessage, err = parse("commit_message", commitMessage, defaultCommitMessageTemplate); err != nil {
+		return nil, err
+	}
+	if t.prTitle, err = parse("pr_title", prTitle, defaultPRTitleTemplate); err != nil {
+		return nil, err
+	}
+	if t.prBody, err = parse("pr_body", prBody, defaultPRBodyTemplate); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// execute renders the template with the commit data
+func execute(t *template.Template, data CommitData) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// CommitMessage renders the commit message
+func (t *Templates) CommitMessage(data CommitData) (string, error) {
+	return execute(t.commitMessage, data)
+}
+
+// PRTitle renders the PR title
+func (t *Templates) PRTitle(data CommitData) (string, error) {
+	return execute(t.prTitle, data)
+}
+
+// PRBody renders the PR body
+func (t *Templates) PRBody(data CommitData) (string, error) {
+	return execute(t.prBody, data)
+}
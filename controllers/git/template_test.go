@@ -0,0 +1,90 @@
+package git
+
+import (
+	"testing"
+)
+
+func TestTemplates(t *testing.T) {
+	single := CommitData{
+		Namespace: "default",
+		Name:      "app",
+		Image:     ImageUpdate{Path: "xxx/api", Registry: "ecr", OldTag: "v1.0.0", NewTag: "v1.1.0"},
+		Images: []ImageUpdate{
+			{Path: "xxx/api", Registry: "ecr", OldTag: "v1.0.0", NewTag: "v1.1.0"},
+		},
+		Files: []string{"overlays/dev/kustomization.yaml"},
+	}
+	multiple := single
+	multiple.Images = append(multiple.Images, ImageUpdate{Path: "xxx/worker", OldTag: "v2.0.0", NewTag: "v2.1.0"})
+
+	tests := []struct {
+		name          string
+		commitMessage string
+		prTitle       string
+		prBody        string
+		data          CommitData
+		results       []string
+	}{
+		{
+			"default templates",
+			"",
+			"",
+			"",
+			single,
+			[]string{
+				"update imageTags to v1.1.0 for xxx/api by gitops-controller",
+				"Release Candidate: v1.1.0",
+				"If you want to deploy version v1.1.0, please merge this PR",
+			},
+		},
+		{
+			"default templates with multiple images",
+			"",
+			"",
+			"",
+			multiple,
+			[]string{
+				"update imageTags to v1.1.0 for xxx/api, v2.1.0 for xxx/worker by gitops-controller",
+				"Release Candidate: v1.1.0, v2.1.0",
+				"If you want to deploy version v1.1.0, v2.1.0, please merge this PR",
+			},
+		},
+		{
+			"custom templates",
+			"chore(deps): bump {{ .Image.Path }} from {{ .Image.OldTag }} to {{ .Image.NewTag }}",
+			"[{{ .Namespace }}/{{ .Name }}] {{ .Image.NewTag }}",
+			"{{ range .Images }}- {{ .Registry }} {{ .Path }}: {{ .OldTag }} -> {{ .NewTag }}\n{{ end }}{{ range .Files }}* {{ . }}\n{{ end }}",
+			single,
+			[]string{
+				"chore(deps): bump xxx/api from v1.0.0 to v1.1.0",
+				"[default/app] v1.1.0",
+				"- ecr xxx/api: v1.0.0 -> v1.1.0\n* overlays/dev/kustomization.yaml\n",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			templates, err := NewTemplates(test.commitMessage, test.prTitle, test.prBody)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			for i, render := range []func(CommitData) (string, error){
+				templates.CommitMessage,
+				templates.PRTitle,
+				templates.PRBody,
+			} {
+				result, err := render(test.data)
+				if err != nil {
+					t.Fatalf("got unexpected error: %s", err.Error())
+				}
+				if result != test.results[i] {
+					t.Errorf("expected %q, got %q", test.results[i], result)
+				}
+			}
+		})
+	}
+
+	if _, err := NewTemplates("{{ .Image.NewTag", "", ""); err == nil {
+		t.Errorf("expected error for invalid template, got nil")
+	}
+}
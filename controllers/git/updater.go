@@ -0,0 +1,80 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/kazylla/gitops-controller/controllers/version"
+)
+
+type UpdaterType int
+
+const (
+	UpdaterKustomize UpdaterType = iota
+	UpdaterHelm
+	UpdaterManifest
+	UpdaterMarker
+)
+
+// Updater updates the image tag in the manifest file
+type Updater interface {
+	// Update returns the updated content of the file, the old tag and whether the file has been updated
+	Update(path string, buf []byte, image Image, imageVer version.ImageVersion) ([]byte, string, bool, error)
+}
+
+// newUpdater creates Updater according to UpdaterType
+func newUpdater(c Config, log logr.Logger) Updater {
+	switch c.Updater {
+	case UpdaterHelm:
+		return &HelmUpdater{
+			config: c,
+			log:    log,
+		}
+	case UpdaterManifest:
+		return &ManifestUpdater{
+			config: c,
+			log:    log,
+		}
+	case UpdaterMarker:
+		return &MarkerUpdater{
+			config: c,
+			log:    log,
+		}
+	default:
+		return &KustomizeUpdater{
+			config: c,
+			log:    log,
+		}
+	}
+}
+
+// digestOf returns the digest if the image version is pinned by the digest
+func digestOf(imageVer version.ImageVersion) string {
+	if d, ok := imageVer.(version.Digester); ok {
+		return d.GetDigest()
+	}
+	return ""
+}
+
+// isNewer checks whether the image version is newer than the current tag on git repository
+// (or the same version whose digest has changed)
+func isNewer(imageVer version.ImageVersion, currentTag, currentDigest string) (bool, error) {
+	result, err := imageVer.Compare(currentTag)
+	if err != nil {
+		return false, err
+	}
+	digest := digestOf(imageVer)
+	if result < 0 || (result == 0 && (digest == "" || digest == currentDigest)) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// tagWithDigest returns the tag value to write, which includes the digest for DigestModeTag
+func tagWithDigest(imageVer version.ImageVersion, digestMode DigestMode) string {
+	digest := digestOf(imageVer)
+	if digest == "" || digestMode == DigestModeField {
+		return imageVer.GetTag()
+	}
+	return fmt.Sprintf("%s@%s", imageVer.GetTag(), digest)
+}
@@ -0,0 +1,235 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlDocument is the parsed YAML file which is edited by patching the source bytes,
+// so that comments, key order and quoting outside of the edited scalars are kept as they are
+type yamlDocument struct {
+	buf     []byte
+	docs    []*yaml.Node
+	patches []yamlPatch
+}
+
+// yamlPatch replaces buf[start:end] with text
+type yamlPatch struct {
+	start int
+	end   int
+	text  string
+}
+
+// parseYAMLDocument parses all documents in the YAML file
+func parseYAMLDocument(buf []byte) (*yamlDocument, error) {
+	doc := &yamlDocument{buf: buf}
+	decoder := yaml.NewDecoder(bytes.NewReader(buf))
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		doc.docs = append(doc.docs, &node)
+	}
+	return doc, nil
+}
+
+// roots returns the top level nodes of the documents
+func (d *yamlDocument) roots() []*yaml.Node {
+	roots := make([]*yaml.Node, 0, len(d.docs))
+	for _, doc := range d.docs {
+		if len(doc.Content) > 0 {
+			roots = append(roots, doc.Content[0])
+		}
+	}
+	return roots
+}
+
+// offset converts the line and the column (1-based, counted in characters) of the node to the byte offset
+func (d *yamlDocument) offset(node *yaml.Node) int {
+	pos := 0
+	for line := 1; line < node.Line; line++ {
+		i := bytes.IndexByte(d.buf[pos:], '\n')
+		if i < 0 {
+			return len(d.buf)
+		}
+		pos += i + 1
+	}
+	for column := 1; column < node.Column && pos < len(d.buf); column++ {
+		_, size := utf8.DecodeRune(d.buf[pos:])
+		pos += size
+	}
+	return pos
+}
+
+// scalarRange returns the byte range of the scalar node in the source
+func (d *yamlDocument) scalarRange(node *yaml.Node) (int, int, error) {
+	if node.Kind != yaml.ScalarNode {
+		return 0, 0, fmt.Errorf("line %d: not a scalar", node.Line)
+	}
+	start := d.offset(node)
+	switch {
+	case node.Style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(d.buf); i++ {
+			switch d.buf[i] {
+			case '\\':
+				i++
+			case '"':
+				return start, i + 1, nil
+			}
+		}
+	case node.Style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(d.buf); i++ {
+			if d.buf[i] != '\'' {
+				continue
+			}
+			if i+1 < len(d.buf) && d.buf[i+1] == '\'' {
+				i++
+				continue
+			}
+			return start, i + 1, nil
+		}
+	case node.Style&(yaml.LiteralStyle|yaml.FoldedStyle|yaml.TaggedStyle) == 0:
+		end := start + len(node.Value)
+		if end <= len(d.buf) && string(d.buf[start:end]) == node.Value {
+			return start, end, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("line %d: unsupported scalar style", node.Line)
+}
+
+// lineEnd returns the offset of the newline (or the end of the file) of the line which includes pos
+func (d *yamlDocument) lineEnd(pos int) int {
+	i := bytes.IndexByte(d.buf[pos:], '\n')
+	if i < 0 {
+		return len(d.buf)
+	}
+	return pos + i
+}
+
+// formatScalar formats the value in the style of the node it replaces
+func formatScalar(value string, style yaml.Style) string {
+	switch {
+	case style&yaml.DoubleQuotedStyle != 0:
+		return strconv.Quote(value)
+	case style&yaml.SingleQuotedStyle != 0:
+		return "'" + strings.Replace(value, "'", "''", -1) + "'"
+	}
+	// quote the value which is not read back as the same string in the plain style (e.g. "1.10" or "true")
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(value), &v); err != nil || v != value || strings.ContainsAny(value, "\n#") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// setScalar replaces the value of the scalar node
+func (d *yamlDocument) setScalar(node *yaml.Node, value string) error {
+	start, end, err := d.scalarRange(node)
+	if err != nil {
+		return err
+	}
+	d.patches = append(d.patches, yamlPatch{start: start, end: end, text: formatScalar(value, node.Style)})
+	node.Value = value
+	return nil
+}
+
+// addKey appends the key to the block mapping, just after the last pair written on a single line
+func (d *yamlDocument) addKey(mapping *yaml.Node, key, value string) error {
+	if mapping.Kind != yaml.MappingNode || mapping.Style&yaml.FlowStyle != 0 {
+		return fmt.Errorf("line %d: unable to add %s to non-block mapping", mapping.Line, key)
+	}
+	for i := len(mapping.Content) - 2; i >= 0; i -= 2 {
+		k, v := mapping.Content[i], mapping.Content[i+1]
+		if v.Kind != yaml.ScalarNode || v.Line != k.Line {
+			continue
+		}
+		_, end, err := d.scalarRange(v)
+		if err != nil {
+			continue
+		}
+		pos := d.lineEnd(end)
+		text := fmt.Sprintf("\n%s%s: %s", strings.Repeat(" ", k.Column-1), key, formatScalar(value, 0))
+		d.patches = append(d.patches, yamlPatch{start: pos, end: pos, text: text})
+		mapping.Content = append(mapping.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+			&yaml.Node{Kind: yaml.ScalarNode, Value: value},
+		)
+		return nil
+	}
+	return fmt.Errorf("line %d: unable to add %s to mapping", mapping.Line, key)
+}
+
+// removeKey removes the line of the key written on its own line from the block mapping
+func (d *yamlDocument) removeKey(mapping *yaml.Node, key string) error {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		k, v := mapping.Content[i], mapping.Content[i+1]
+		if k.Value != key {
+			continue
+		}
+		start := d.offset(k)
+		lineStart := bytes.LastIndexByte(d.buf[:start], '\n') + 1
+		if v.Kind != yaml.ScalarNode || v.Line != k.Line || strings.TrimSpace(string(d.buf[lineStart:start])) != "" {
+			return fmt.Errorf("line %d: unable to remove %s", k.Line, key)
+		}
+		end := d.lineEnd(start)
+		if end < len(d.buf) {
+			end++
+		}
+		d.patches = append(d.patches, yamlPatch{start: lineStart, end: end})
+		mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+		return nil
+	}
+	return nil
+}
+
+// bytes applies the patches and returns the edited source
+func (d *yamlDocument) bytes() ([]byte, error) {
+	patches := make([]yamlPatch, len(d.patches))
+	copy(patches, d.patches)
+	sort.SliceStable(patches, func(i, j int) bool {
+		return patches[i].start < patches[j].start
+	})
+
+	var out bytes.Buffer
+	pos := 0
+	for _, p := range patches {
+		if p.start < pos {
+			return nil, fmt.Errorf("overlapped yaml edits")
+		}
+		out.Write(d.buf[pos:p.start])
+		out.WriteString(p.text)
+		pos = p.end
+	}
+	out.Write(d.buf[pos:])
+
+	// make sure that the edited source is still valid
+	if _, err := parseYAMLDocument(out.Bytes()); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// mappingValue returns the value node of the key in the mapping node, or nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestFormatScalar(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		style  yaml.Style
+		result string
+	}{
+		{
+			"plain",
+			"v1.0.0",
+			0,
+			"v1.0.0",
+		},
+		{
+			"plain with digest",
+			"v1.0.0@sha256:1111",
+			0,
+			"v1.0.0@sha256:1111",
+		},
+		{
+			"plain looks like a number",
+			"1.10",
+			0,
+			`"1.10"`,
+		},
+		{
+			"double quoted",
+			"v1.0.0",
+			yaml.DoubleQuotedStyle,
+			`"v1.0.0"`,
+		},
+		{
+			"single quoted",
+			"it's",
+			yaml.SingleQuotedStyle,
+			`'it''s'`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := formatScalar(test.value, test.style)
+			if result != test.result {
+				t.Errorf("expected %s, got %s", test.result, result)
+			}
+		})
+	}
+}
+
+func TestYAMLDocument_SetScalar(t *testing.T) {
+	// multi-byte characters before the scalar and multiple documents
+	src := "# 開発環境\nimage: {tag: v1.0.0}\n---\nimage:\n  tag: v1.0.0 # 最新\n"
+	doc, err := parseYAMLDocument([]byte(src))
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	for _, root := range doc.roots() {
+		if err := doc.setScalar(mappingValue(mappingValue(root, "image"), "tag"), "v1.1.0"); err != nil {
+			t.Fatalf("got unexpected error: %s", err.Error())
+		}
+	}
+	buf, err := doc.bytes()
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	expected := "# 開発環境\nimage: {tag: v1.1.0}\n---\nimage:\n  tag: v1.1.0 # 最新\n"
+	if string(buf) != expected {
+		t.Errorf("expected %q, got %q", expected, string(buf))
+	}
+}
@@ -2,7 +2,11 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/kazylla/gitops-controller/controllers/git"
 
@@ -13,28 +17,47 @@ import (
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/metadata/metadatainformer"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	gitopsv1 "github.com/kazylla/gitops-controller/api/v1"
 )
 
+// minRateLimitBackoff is the requeue interval when the registry does not report when the rate limit is recovered
+const minRateLimitBackoff = time.Minute
+
 // GitOpsReconciler reconciles a GitOps object
 type GitOpsReconciler struct {
 	client.Client
+	// APIReader reads the secrets from the API server, so that the secrets are not cached
+	APIReader   client.Reader
 	Log         logr.Logger
 	Scheme      *runtime.Scheme
 	GitUsername string
 	GitPassword string
-	Recorder    record.EventRecorder
+	// RegHost is the registry host to which RegUsername and RegPassword are sent
+	RegHost     string
+	RegUsername string
+	RegPassword string
+	// DockerHubUsername and DockerHubPassword are sent only to Docker Hub instead of RegUsername and RegPassword
+	DockerHubUsername string
+	DockerHubPassword string
+	Recorder          record.EventRecorder
 }
 
 // +kubebuilder:rbac:groups=gitops.kazylla.jp,resources=gitops,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=gitops.kazylla.jp,resources=gitops/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 
-// Reconcile periodically gets a new tag from the ECR and updates the image tag in the git repository with that tag
+// Reconcile periodically gets a new tag from the configured registry and updates the image tag in the git repository with that tag
 func (r *GitOpsReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
 	log := r.Log.WithValues("gitops", req.NamespacedName)
@@ -50,82 +73,261 @@ func (r *GitOpsReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	}
 
 	log.V(1).Info("GitOps Resource",
+		"registry_type", gitOps.Spec.RegistryType,
 		"image_path", gitOps.Spec.ImagePath,
 		"image_tag_format", gitOps.Spec.ImageTagFormat,
+		"image_tag", gitOps.Spec.ImageTag,
+		"image_tag_constraint", gitOps.Spec.ImageTagConstraint,
+		"images", len(gitOps.Spec.Images),
 		"git_repo", gitOps.Spec.GitRepo,
 		"git_branch", gitOps.Spec.GitBranch,
 		"git_release_branch", gitOps.Spec.GitReleaseBranch,
 		"git_paths", gitOps.Spec.GitPaths,
+		"git_updater", gitOps.Spec.GitUpdater,
+		"git_provider", gitOps.Spec.GitProvider,
+		"git_api_url", gitOps.Spec.GitAPIURL,
+		"git_commit_policy", gitOps.Spec.GitCommitPolicy,
 		"git_commit_name", gitOps.Spec.GitCommitName,
 		"git_commit_email", gitOps.Spec.GitCommitEmail,
 	)
 
-	// convert tag format
-	var tagFmt version.TagFormat
-	switch gitOps.Spec.ImageTagFormat {
-	case "serial":
-		tagFmt = version.TagFormatSerial
-	case "semantic":
-		tagFmt = version.TagFormatSemantic
+	// the images list takes precedence over the image of the spec itself
+	imageSpecs := gitOps.Spec.Images
+	if len(imageSpecs) == 0 {
+		imageSpecs = []gitopsv1.ImageSpec{gitOps.Spec.ImageSpec}
+	}
+
+	// convert updater type
+	var updater git.UpdaterType
+	switch gitOps.Spec.GitUpdater {
+	case "", "kustomize":
+		updater = git.UpdaterKustomize
+	case "helm":
+		updater = git.UpdaterHelm
+	case "manifest":
+		updater = git.UpdaterManifest
+	case "marker":
+		updater = git.UpdaterMarker
 	default:
-		log.Info("invalid tag format", "format", gitOps.Spec.ImageTagFormat)
+		log.Info("invalid updater", "updater", gitOps.Spec.GitUpdater)
 		return ctrl.Result{}, nil
 	}
 
-	// get filtered tags
-	log.Info("scanning docker registry", "image_tag_format", gitOps.Spec.ImageTagFormat, "current_tag", gitOps.Status.CurrentTag)
-	ecrRegistry := registry.NewRegistry(registry.Config{
-		Path:      gitOps.Spec.ImagePath,
-		TagFormat: tagFmt,
-		Log:       log,
-		AWSCred: registry.AWSCred{
-			Profile: gitOps.Spec.AWSProfile,
-		},
-	})
+	// convert commit policy
+	var commitPolicy git.CommitPolicy
+	switch gitOps.Spec.GitCommitPolicy {
+	case "", "latest":
+		commitPolicy = git.CommitPolicyLatest
+	case "every":
+		commitPolicy = git.CommitPolicyEvery
+	default:
+		log.Info("invalid commit policy", "policy", gitOps.Spec.GitCommitPolicy)
+		return ctrl.Result{}, nil
+	}
+
+	// convert PR provider
+	var provider git.Provider
+	switch gitOps.Spec.GitProvider {
+	case "":
+		provider = git.ProviderAuto
+	case "github":
+		provider = git.ProviderGithub
+	case "gitlab":
+		provider = git.ProviderGitlab
+	case "bitbucket":
+		provider = git.ProviderBitbucket
+	case "bitbucket-server":
+		provider = git.ProviderBitbucketServer
+	case "gitea":
+		provider = git.ProviderGitea
+	default:
+		log.Info("invalid provider", "provider", gitOps.Spec.GitProvider)
+		return ctrl.Result{}, nil
+	}
 
-	imageVers, err := ecrRegistry.GetTags(gitOps.Status.CurrentTag)
+	// parse commit message and PR templates
+	templates, err := git.NewTemplates(gitOps.Spec.GitCommitMessageTemplate, gitOps.Spec.GitPRTitleTemplate, gitOps.Spec.GitPRBodyTemplate)
 	if err != nil {
-		return ctrl.Result{}, err
+		log.Info("invalid template", "error", err.Error())
+		return ctrl.Result{}, nil
 	}
 
-	// sort image version by ascending
-	sort.Slice(imageVers, func(i, j int) bool {
-		result, _ := imageVers[i].Compare(imageVers[j].GetTag())
-		return result < 0
-	})
-	log.Info("scanning docker registry has succeeded", "new", len(imageVers))
+	// convert signing key format
+	var signingFormat git.SigningFormat
+	if gitOps.Spec.GitSigningKey != nil {
+		switch gitOps.Spec.GitSigningKey.Format {
+		case "", "openpgp":
+			signingFormat = git.SigningFormatOpenPGP
+		case "ssh":
+			signingFormat = git.SigningFormatSSH
+		default:
+			log.Info("invalid signing key format", "format", gitOps.Spec.GitSigningKey.Format)
+			return ctrl.Result{}, nil
+		}
+	}
 
-	if len(imageVers) == 0 {
+	// get new versions of each image
+	images := make([]git.Image, 0, len(imageSpecs))
+	digests := make([]string, 0, len(imageSpecs))
+	newVersions := 0
+	for _, imageSpec := range imageSpecs {
+		imageLog := log.WithValues("image_path", imageSpec.ImagePath)
+		if imageSpec.ImagePath == "" {
+			imageLog.Info("image_path is required")
+			return ctrl.Result{}, nil
+		}
+
+		image, digest, err := r.scanImage(ctx, imageLog, req.Namespace, imageSpec, currentImage(&gitOps, imageSpec.ImagePath), commitPolicy)
+		if err != nil {
+			// requeue after the rate limit is recovered instead of failing
+			var rateLimitErr *registry.RateLimitError
+			if errors.As(err, &rateLimitErr) {
+				retryAfter := rateLimitErr.RetryAfter
+				if retryAfter < minRateLimitBackoff {
+					retryAfter = minRateLimitBackoff
+				}
+				imageLog.Info("registry rate limit is near, requeue", "remaining", rateLimitErr.Remaining, "retry_after", retryAfter)
+				return ctrl.Result{RequeueAfter: retryAfter}, nil
+			}
+			return ctrl.Result{}, err
+		}
+		if image == nil {
+			// invalid spec
+			return ctrl.Result{}, nil
+		}
+		images = append(images, *image)
+		digests = append(digests, digest)
+		newVersions += len(image.Versions)
+	}
+
+	if newVersions == 0 {
+		// git_paths are checked only when the repository is cloned to commit, so the last result is kept until then
+		if hasCondition(&gitOps.Status, gitopsv1.GitOpsPathsMissing) {
+			return ctrl.Result{}, nil
+		}
+		pathsCondition := gitopsv1.GitOpsCondition{
+			Type:    gitopsv1.GitOpsPathsMissing,
+			Status:  corev1.ConditionUnknown,
+			Reason:  "NotChecked",
+			Message: "git_paths are checked when there are new versions to commit",
+		}
+		if setCondition(&gitOps.Status, pathsCondition) {
+			if err := r.Status().Update(ctx, &gitOps); err != nil {
+				log.Error(err, "unable to update GitOps status")
+				return ctrl.Result{}, err
+			}
+		}
 		return ctrl.Result{}, nil
 	}
 
+	// read the credentials from the secret instead of the environment variables
+	username, password := r.GitUsername, r.GitPassword
+	var tokenAuth bool
+	var sshKey *git.SSHKey
+	var gitHubApp *git.GitHubApp
+	if ref := gitOps.Spec.GitCredentialsSecretRef; ref != nil {
+		credentials, err := r.readCredentials(ctx, req.Namespace, ref.Name)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		username, password, tokenAuth = credentials.Username, credentials.Password, credentials.TokenAuth
+		sshKey, gitHubApp = credentials.SSHKey, credentials.GitHubApp
+		if gitHubApp != nil && provider == git.ProviderGithub {
+			// the installation token is created by GitHub Enterprise Server if specified
+			gitHubApp.BaseURL = gitOps.Spec.GitAPIURL
+		}
+	}
+
+	// read the SSH key from the secret, which takes precedence over the credentials
+	if gitOps.Spec.GitSSHKey != nil {
+		sshKey, err = r.readSSHKey(ctx, req.Namespace, gitOps.Spec.GitSSHKey)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// read the signing key from the secret
+	var signingKey *git.SigningKey
+	if gitOps.Spec.GitSigningKey != nil {
+		signingKey, err = r.readSigningKey(ctx, req.Namespace, gitOps.Spec.GitSigningKey, signingFormat)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// commit uncommitted tags from oldest
 	gitRepo, err := git.NewGitRepo(git.Config{
-		ImagePath:     gitOps.Spec.ImagePath,
+		Namespace:     req.Namespace,
+		Name:          req.Name,
+		Updater:       updater,
+		CommitPolicy:  commitPolicy,
+		Templates:     templates,
 		Repo:          gitOps.Spec.GitRepo,
 		Branch:        gitOps.Spec.GitBranch,
 		ReleaseBranch: gitOps.Spec.GitReleaseBranch,
 		Paths:         gitOps.Spec.GitPaths,
 		CommitName:    gitOps.Spec.GitCommitName,
 		CommitEmail:   gitOps.Spec.GitCommitEmail,
-		Username:      r.GitUsername,
-		Password:      r.GitPassword,
+		Username:      username,
+		Password:      password,
+		TokenAuth:     tokenAuth,
+		GitHubApp:     gitHubApp,
+		SSHKey:        sshKey,
+		SigningKey:    signingKey,
 		Log:           log,
+
+		Provider:             provider,
+		APIURL:               gitOps.Spec.GitAPIURL,
+		PRLabels:             gitOps.Spec.GitPRLabels,
+		PRAssignees:          gitOps.Spec.GitPRAssignees,
+		PRRemoveSourceBranch: gitOps.Spec.GitPRRemoveSourceBranch,
 	})
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	latestTag, err := gitRepo.CommitTags(imageVers)
+	// report git_paths which match no file as a warning instead of failing
+	pathsCondition := gitopsv1.GitOpsCondition{
+		Type:   gitopsv1.GitOpsPathsMissing,
+		Status: corev1.ConditionFalse,
+	}
+	if missingPaths := gitRepo.MissingPaths(); len(missingPaths) > 0 {
+		log.Info("some git_paths match no file", "paths", missingPaths)
+		pathsCondition.Status = corev1.ConditionTrue
+		pathsCondition.Reason = "NoMatchingFile"
+		pathsCondition.Message = fmt.Sprintf("no file matches git_paths: %s", strings.Join(missingPaths, ", "))
+	}
+	conditionChanged := setCondition(&gitOps.Status, pathsCondition)
+	if conditionChanged && pathsCondition.Status == corev1.ConditionTrue {
+		r.Recorder.Event(&gitOps, corev1.EventTypeWarning, pathsCondition.Reason, pathsCondition.Message)
+	}
+
+	err = gitRepo.CommitTags(images)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// update CurrentTag status to latest tag
-	if gitOps.Status.CurrentTag != latestTag {
-
-		log.Info("all uncommited tags has commited", "latest_tag", latestTag)
-		gitOps.Status.CurrentTag = latestTag
+	// update current tags (and digests) status to latest tags
+	updatedImages := make([]gitopsv1.ImageStatus, 0)
+	for i, image := range images {
+		current := currentImage(&gitOps, image.Path)
+		latestTag := current.CurrentTag
+		if len(image.Versions) > 0 {
+			latestTag = image.Versions[len(image.Versions)-1].GetTag()
+		}
+		if current.CurrentTag == latestTag && current.CurrentDigest == digests[i] {
+			continue
+		}
+		log.Info("all uncommited tags has commited", "image_path", image.Path, "latest_tag", latestTag, "digest", digests[i])
+		current = gitopsv1.ImageStatus{
+			ImagePath:     image.Path,
+			CurrentTag:    latestTag,
+			CurrentDigest: digests[i],
+		}
+		setCurrentImage(&gitOps, current)
+		updatedImages = append(updatedImages, current)
+	}
+	if len(updatedImages) > 0 || conditionChanged {
 
 		// update gitops.status
 		if err := r.Status().Update(ctx, &gitOps); err != nil {
@@ -134,14 +336,320 @@ func (r *GitOpsReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 		}
 
 		// create event for updated gitops.status
-		r.Recorder.Eventf(&gitOps, corev1.EventTypeNormal, "Updated", "Update gitops.status.current_tag: %s", gitOps.Status.CurrentTag)
+		for _, image := range updatedImages {
+			r.Recorder.Eventf(&gitOps, corev1.EventTypeNormal, "Updated", "Update gitops.status.current_tag: %s for %s", image.CurrentTag, image.ImagePath)
+		}
 	}
 
 	return ctrl.Result{}, nil
 }
 
+// scanImage converts the image spec, and gets the new versions of the image from the registry.
+// It returns nil image if the spec is invalid.
+func (r *GitOpsReconciler) scanImage(ctx context.Context, log logr.Logger, namespace string, image gitopsv1.ImageSpec, current gitopsv1.ImageStatus, commitPolicy git.CommitPolicy) (*git.Image, string, error) {
+	// convert tag format
+	var tagFmt version.TagFormat
+	var tagOpts version.Options
+	var followDigest bool
+	switch image.ImageTagFormat {
+	case "serial":
+		tagFmt = version.TagFormatSerial
+	case "semantic":
+		tagFmt = version.TagFormatSemantic
+	case "digest":
+		followDigest = true
+		if image.ImageTag == "" {
+			log.Info("image_tag is required for digest tag format")
+			return nil, "", nil
+		}
+	case "timestamp":
+		tagFmt = version.TagFormatTimestamp
+	case "calver":
+		tagFmt = version.TagFormatCalVer
+		var err error
+		tagOpts, err = version.NewCalVerOptions(image.ImageTagCalVerLayout)
+		if err != nil {
+			log.Info("invalid image_tag_calver_layout", "error", err.Error())
+			return nil, "", nil
+		}
+	case "regex":
+		tagFmt = version.TagFormatRegex
+		if image.ImageTagRegex == nil {
+			log.Info("image_tag_regex is required for regex tag format")
+			return nil, "", nil
+		}
+		groups := make([]version.RegexGroup, 0)
+		for _, g := range image.ImageTagRegex.Groups {
+			group := version.RegexGroup{Name: g.Name}
+			switch g.Order {
+			case "", "numerical":
+				group.Order = version.RegexOrderNumerical
+			case "lexical":
+				group.Order = version.RegexOrderLexical
+			default:
+				log.Info("invalid regex group order", "group", g.Name, "order", g.Order)
+				return nil, "", nil
+			}
+			groups = append(groups, group)
+		}
+		var err error
+		tagOpts, err = version.NewRegexOptions(image.ImageTagRegex.Pattern, groups)
+		if err != nil {
+			log.Info("invalid image_tag_regex", "error", err.Error())
+			return nil, "", nil
+		}
+	default:
+		log.Info("invalid tag format", "format", image.ImageTagFormat)
+		return nil, "", nil
+	}
+
+	// convert digest mode
+	gitImage := &git.Image{
+		Name:      image.ImageName,
+		Path:      image.ImagePath,
+		Registry:  image.RegistryType,
+		ValueKeys: image.GitValueKeys,
+	}
+	switch image.ImageDigestMode {
+	case "", "tag":
+		gitImage.DigestMode = git.DigestModeTag
+	case "digest":
+		gitImage.DigestMode = git.DigestModeField
+	default:
+		log.Info("invalid digest mode", "mode", image.ImageDigestMode)
+		return nil, "", nil
+	}
+
+	// parse tag constraint
+	var tagConstraint *version.Constraint
+	if image.ImageTagConstraint != "" {
+		var err error
+		tagConstraint, err = version.ParseConstraint(image.ImageTagConstraint)
+		if err != nil {
+			log.Info("invalid image_tag_constraint", "error", err.Error())
+			return nil, "", nil
+		}
+	}
+
+	// convert registry type
+	var regType registry.RegType
+	switch image.RegistryType {
+	case "", "ecr":
+		regType = registry.RegECR
+		gitImage.Registry = "ecr"
+	case "docker":
+		regType = registry.RegDocker
+	case "gcr":
+		regType = registry.RegGCR
+	case "acr":
+		regType = registry.RegACR
+	case "dockerhub":
+		regType = registry.RegDockerHub
+	default:
+		log.Info("invalid registry type", "type", image.RegistryType)
+		return nil, "", nil
+	}
+
+	// read the registry credentials from the secrets
+	var gcpCredentials []byte
+	if image.GCPCredentialsSecretRef != nil {
+		var err error
+		gcpCredentials, err = r.readSecretKey(ctx, namespace, image.GCPCredentialsSecretRef)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	var azureClientSecret []byte
+	if image.AzureClientSecretRef != nil {
+		var err error
+		azureClientSecret, err = r.readSecretKey(ctx, namespace, image.AzureClientSecretRef)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	basicCred, err := r.registryCredentials(ctx, namespace, &image, regType)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// get filtered tags
+	log.Info("scanning docker registry", "image_tag_format", image.ImageTagFormat, "current_tag", current.CurrentTag)
+	imageRegistry := registry.NewRegistry(registry.Config{
+		Type:       regType,
+		Path:       image.ImagePath,
+		TagFormat:  tagFmt,
+		TagOptions: tagOpts,
+		Log:        log,
+
+		IncludeCurrentTag: image.ImagePinDigest,
+
+		TagConstraint:   tagConstraint,
+		AllowPreRelease: image.ImageTagAllowPreRelease,
+
+		AWSCred: registry.AWSCred{
+			Profile: image.AWSProfile,
+		},
+		GCPCred: registry.GCPCred{
+			CredentialsJSON: gcpCredentials,
+		},
+		AzureCred: registry.AzureCred{
+			TenantID:     image.AzureTenantID,
+			ClientID:     image.AzureClientID,
+			ClientSecret: strings.TrimSpace(string(azureClientSecret)),
+		},
+		BasicCred: basicCred,
+		PlainHTTP: image.RegistryPlainHTTP,
+	})
+
+	var imageVers []version.ImageVersion
+	var digest string
+	if followDigest {
+		// follow the mutable tag, and regard the changed digest as a new version
+		digest, err = imageRegistry.GetDigest(image.ImageTag)
+		if err == nil {
+			imageVers = make([]version.ImageVersion, 0)
+			if digest != current.CurrentDigest {
+				log.V(1).Info("new digest found", "tag", image.ImageTag, "digest", digest)
+				imageVers = append(imageVers, version.NewDigestImageVersion(image.ImageTag, digest))
+			}
+		}
+	} else {
+		imageVers, err = imageRegistry.GetTags(current.CurrentTag)
+		if err == nil && image.ImagePinDigest {
+			imageVers, digest, err = r.pinDigests(imageRegistry, imageVers, current, commitPolicy)
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	sortImageVersions(imageVers)
+	log.Info("scanning docker registry has succeeded", "new", len(imageVers))
+
+	gitImage.Versions = imageVers
+	return gitImage, digest, nil
+}
+
+// currentImage returns the current tag of the image in the status.
+// The image of the spec itself (without the images list) is also reported by status.current_tag.
+func currentImage(gitOps *gitopsv1.GitOps, imagePath string) gitopsv1.ImageStatus {
+	for _, image := range gitOps.Status.Images {
+		if image.ImagePath == imagePath {
+			return image
+		}
+	}
+	if len(gitOps.Spec.Images) == 0 {
+		return gitopsv1.ImageStatus{
+			ImagePath:     imagePath,
+			CurrentTag:    gitOps.Status.CurrentTag,
+			CurrentDigest: gitOps.Status.CurrentDigest,
+		}
+	}
+	return gitopsv1.ImageStatus{ImagePath: imagePath}
+}
+
+// setCurrentImage sets the current tag of the image to the status
+func setCurrentImage(gitOps *gitopsv1.GitOps, current gitopsv1.ImageStatus) {
+	if len(gitOps.Spec.Images) == 0 {
+		gitOps.Status.CurrentTag = current.CurrentTag
+		gitOps.Status.CurrentDigest = current.CurrentDigest
+	}
+	for i, image := range gitOps.Status.Images {
+		if image.ImagePath == current.ImagePath {
+			gitOps.Status.Images[i] = current
+			return
+		}
+	}
+	gitOps.Status.Images = append(gitOps.Status.Images, current)
+}
+
+// sortImageVersions sorts the image versions by ascending
+func sortImageVersions(imageVers []version.ImageVersion) {
+	sort.Slice(imageVers, func(i, j int) bool {
+		result, _ := imageVers[i].Compare(imageVers[j].GetTag())
+		return result < 0
+	})
+}
+
+// pinDigests pins the image versions by the digests, excluding the current tag which has not been re-pushed.
+// With CommitPolicyLatest, only the digest of the newest version is resolved, since the others are not committed.
+// It also returns the digest of the latest version.
+func (r *GitOpsReconciler) pinDigests(imageRegistry registry.Registry, imageVers []version.ImageVersion, current gitopsv1.ImageStatus, commitPolicy git.CommitPolicy) ([]version.ImageVersion, string, error) {
+	sortImageVersions(imageVers)
+	if n := len(imageVers); commitPolicy == git.CommitPolicyLatest && n > 1 {
+		imageVers = imageVers[n-1:]
+	}
+
+	var latestDigest string
+	pinnedVers := make([]version.ImageVersion, 0, len(imageVers))
+	for _, v := range imageVers {
+		digest, err := imageRegistry.GetDigest(v.GetTag())
+		if err != nil {
+			return nil, "", err
+		}
+		latestDigest = digest
+		if v.GetTag() == current.CurrentTag && digest == current.CurrentDigest {
+			continue
+		}
+		pinnedVers = append(pinnedVers, version.WithDigest(v, digest))
+	}
+	return pinnedVers, latestDigest, nil
+}
+
+// hasCondition reports whether the status has the condition of the type
+func hasCondition(status *gitopsv1.GitOpsStatus, conditionType gitopsv1.GitOpsConditionType) bool {
+	for _, c := range status.Conditions {
+		if c.Type == conditionType {
+			return true
+		}
+	}
+	return false
+}
+
+// setCondition sets the condition to the status, and reports whether the condition has changed
+func setCondition(status *gitopsv1.GitOpsStatus, condition gitopsv1.GitOpsCondition) bool {
+	for i, c := range status.Conditions {
+		if c.Type != condition.Type {
+			continue
+		}
+		if c.Status == condition.Status && c.Reason == condition.Reason && c.Message == condition.Message {
+			return false
+		}
+		condition.LastTransitionTime = c.LastTransitionTime
+		if c.Status != condition.Status {
+			condition.LastTransitionTime = metav1.Now()
+		}
+		status.Conditions[i] = condition
+		return true
+	}
+	condition.LastTransitionTime = metav1.Now()
+	status.Conditions = append(status.Conditions, condition)
+	return true
+}
+
 func (r *GitOpsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// watch only the metadata of the secrets, so that the data of all secrets in the cluster is not cached
+	metadataClient, err := metadata.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	informers := metadatainformer.NewSharedInformerFactory(metadataClient, 0)
+	secretInformer := informers.ForResource(corev1.SchemeGroupVersion.WithResource("secrets")).Informer()
+	err = mgr.Add(manager.RunnableFunc(func(stop <-chan struct{}) error {
+		informers.Start(stop)
+		<-stop
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&gitopsv1.GitOps{}).
+		Watches(&source.Informer{Informer: secretInformer}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: handler.ToRequestsFunc(r.gitOpsForSecret),
+		}).
 		Complete(r)
 }
@@ -0,0 +1,239 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/kazylla/gitops-controller/controllers/version"
+)
+
+const (
+	azureDefaultAuthorityHost = "https://login.microsoftonline.com/"
+	azureManagementScope      = "https://management.azure.com/.default"
+
+	// acrRefreshTokenUsername is the username to use the ACR refresh token as the password
+	acrRefreshTokenUsername = "00000000-0000-0000-0000-000000000000"
+)
+
+type ACRRegistry struct {
+	Config Config
+
+	// client is authenticated once, and reused by GetTags and GetDigest
+	client *v2Client
+}
+
+type ACRRegistryPath struct {
+	Host         string
+	RegistryName string
+	Repo         string
+}
+
+// parseRegistryPath parses ACR registry path
+func (a *ACRRegistry) parseRegistryPath() (*ACRRegistryPath, error) {
+	// validate acr registry path format (<name>.azurecr.io/<repo>)
+	pathParts := strings.SplitN(a.Config.Path, "/", 2)
+	if len(pathParts) != 2 || pathParts[1] == "" {
+		return nil, fmt.Errorf("invalid acr registry path")
+	}
+	pathParts2 := strings.SplitN(pathParts[0], ".", 2)
+	if len(pathParts2) != 2 || pathParts2[0] == "" || pathParts2[1] != "azurecr.io" {
+		return nil, fmt.Errorf("invalid acr registry path")
+	}
+
+	return &ACRRegistryPath{
+		Host:         pathParts[0],
+		RegistryName: pathParts2[0],
+		Repo:         pathParts[1],
+	}, nil
+}
+
+// azureCred fills unspecified Azure credentials from the environment variables
+// which are also used by the Azure SDK and the workload identity webhook
+func (a *ACRRegistry) azureCred() AzureCred {
+	cred := a.Config.AzureCred
+	if cred.TenantID == "" {
+		cred.TenantID = os.Getenv("AZURE_TENANT_ID")
+	}
+	if cred.ClientID == "" {
+		cred.ClientID = os.Getenv("AZURE_CLIENT_ID")
+	}
+	if cred.ClientSecret == "" {
+		cred.ClientSecret = os.Getenv("AZURE_CLIENT_SECRET")
+	}
+	if cred.FederatedTokenFile == "" {
+		cred.FederatedTokenFile = os.Getenv("AZURE_FEDERATED_TOKEN_FILE")
+	}
+	if cred.AuthorityHost == "" {
+		cred.AuthorityHost = os.Getenv("AZURE_AUTHORITY_HOST")
+	}
+	if cred.AuthorityHost == "" {
+		cred.AuthorityHost = azureDefaultAuthorityHost
+	}
+	return cred
+}
+
+// postForm posts the form and decodes the JSON response into out
+func postForm(endpoint string, form url.Values, out interface{}) error {
+	resp, err := http.PostForm(endpoint, form)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("unable to post %s: %s: %s", endpoint, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// aadToken gets an AAD access token with the service principal secret or the federated token (workload identity)
+func (a *ACRRegistry) aadToken(cred AzureCred) (string, error) {
+	form := url.Values{}
+	form.Set("client_id", cred.ClientID)
+	form.Set("scope", azureManagementScope)
+	form.Set("grant_type", "client_credentials")
+	switch {
+	case cred.ClientSecret != "":
+		form.Set("client_secret", cred.ClientSecret)
+	case cred.FederatedTokenFile != "":
+		assertion, err := ioutil.ReadFile(cred.FederatedTokenFile)
+		if err != nil {
+			return "", err
+		}
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	default:
+		return "", fmt.Errorf("neither azure client secret nor federated token file is configured")
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(cred.AuthorityHost, "/"), cred.TenantID)
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := postForm(endpoint, form, &tokenResp); err != nil {
+		return "", err
+	}
+	return tokenResp.AccessToken, nil
+}
+
+// refreshToken exchanges the AAD access token for an ACR refresh token
+func (a *ACRRegistry) refreshToken(host, tenantID, aadToken string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "access_token")
+	form.Set("service", host)
+	form.Set("tenant", tenantID)
+	form.Set("access_token", aadToken)
+
+	var exchangeResp struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := postForm(fmt.Sprintf("https://%s/oauth2/exchange", host), form, &exchangeResp); err != nil {
+		return "", err
+	}
+	return exchangeResp.RefreshToken, nil
+}
+
+// newClient creates v2Client authenticated with the ACR refresh token, or returns the client already created
+func (a *ACRRegistry) newClient(path *ACRRegistryPath, log logr.Logger) (*v2Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+
+	// exchange aad token for acr refresh token
+	cred := a.azureCred()
+	aadToken, err := a.aadToken(cred)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := a.refreshToken(path.Host, cred.TenantID, aadToken)
+	if err != nil {
+		return nil, err
+	}
+	log.V(1).Info("acr refresh token created", "tenant_id", cred.TenantID, "client_id", cred.ClientID)
+
+	// the token server accepts the refresh token as the password of the null guid user
+	a.client = newV2Client(path.Host, path.Repo, BasicCred{
+		Username: acrRefreshTokenUsername,
+		Password: refreshToken,
+	}, false, log)
+	return a.client, nil
+}
+
+// listTags gets all tags with their creation times by the ACR tag listing API
+func (a *ACRRegistry) listTags(client *v2Client) ([]Tag, error) {
+	next := client.baseURL.ResolveReference(&url.URL{
+		Path:     fmt.Sprintf("/acr/v1/%s/_tags", client.repo),
+		RawQuery: "n=100",
+	})
+
+	tags := make([]Tag, 0)
+	for next != nil {
+		var tagList struct {
+			Tags []struct {
+				Name        string    `json:"name"`
+				Digest      string    `json:"digest"`
+				CreatedTime time.Time `json:"createdTime"`
+			} `json:"tags"`
+		}
+		var err error
+		next, err = client.getJSON(next, &tagList)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tagList.Tags {
+			tags = append(tags, Tag{Name: t.Name, Digest: t.Digest, Created: t.CreatedTime})
+		}
+	}
+	return tags, nil
+}
+
+// GetTags filters and gets newer tags than the specified current tag
+func (a *ACRRegistry) GetTags(currentTag string) ([]version.ImageVersion, error) {
+
+	path, err := a.parseRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	c := a.Config
+	log := c.Log.WithValues("image_repo", path.Repo)
+	log.V(1).Info("GetTags", "registry", path.RegistryName, "repo", path.Repo)
+
+	client, err := a.newClient(path, log)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := a.listTags(client)
+	if err != nil {
+		return nil, err
+	}
+
+	// filter tags
+	imageVers := filterTags(c, log, tags, currentTag)
+	return imageVers, nil
+}
+
+// GetDigest gets the manifest digest of the specified tag
+func (a *ACRRegistry) GetDigest(tag string) (string, error) {
+
+	path, err := a.parseRegistryPath()
+	if err != nil {
+		return "", err
+	}
+
+	log := a.Config.Log.WithValues("image_repo", path.Repo)
+	log.V(1).Info("GetDigest", "registry", path.RegistryName, "repo", path.Repo, "tag", tag)
+
+	client, err := a.newClient(path, log)
+	if err != nil {
+		return "", err
+	}
+	return client.manifestDigest(tag)
+}
@@ -0,0 +1,97 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestACRRegistry_parseRegistryPath(t *testing.T) {
+	tests := []struct {
+		name         string
+		path         string
+		registryName string
+		repo         string
+		err          bool
+	}{
+		{
+			"valid path",
+			"myregistry.azurecr.io/team/app",
+			"myregistry",
+			"team/app",
+			false,
+		},
+		{
+			"without repository",
+			"myregistry.azurecr.io",
+			"",
+			"",
+			true,
+		},
+		{
+			"not an acr path",
+			"myregistry.example.com/team/app",
+			"",
+			"",
+			true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := &ACRRegistry{Config: Config{Path: test.path}}
+			path, err := a.parseRegistryPath()
+			if test.err {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if path.RegistryName != test.registryName || path.Repo != test.repo {
+				t.Errorf("expected %s %s, got %s %s", test.registryName, test.repo, path.RegistryName, path.Repo)
+			}
+		})
+	}
+}
+
+func TestACRRegistry_listTags(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/acr/v1/team/app/_tags" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</acr/v1/team/app/_tags?last=v1.0.0&n=100>; rel="next"`)
+			fmt.Fprint(w, `{"tags":[{"name":"v1.0.0","createdTime":"2024-05-01T10:00:00.0000000Z"}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"tags":[{"name":"v1.1.0","createdTime":"2024-05-02T10:00:00.0000000Z"}]}`)
+	}))
+	defer srv.Close()
+
+	a := &ACRRegistry{}
+	client := newV2Client(strings.TrimPrefix(srv.URL, "http://"), "team/app", BasicCred{}, true, log.NullLogger{})
+	tags, err := a.listTags(client)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	expected := []Tag{
+		{Name: "v1.0.0", Created: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)},
+		{Name: "v1.1.0", Created: time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC)},
+	}
+	if len(tags) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, tags)
+	}
+	for i := range expected {
+		if tags[i].Name != expected[i].Name || !tags[i].Created.Equal(expected[i].Created) {
+			t.Errorf("expected %v, got %v", expected[i], tags[i])
+		}
+	}
+}
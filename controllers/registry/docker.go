@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/kazylla/gitops-controller/controllers/version"
+)
+
+type DockerRegistry struct {
+	Config Config
+
+	// client is reused by GetTags and GetDigest, which keeps the bearer token
+	client *v2Client
+}
+
+type DockerRegistryPath struct {
+	Host string
+	Repo string
+}
+
+// parseRegistryPath parses Docker registry path
+func (d *DockerRegistry) parseRegistryPath() (*DockerRegistryPath, error) {
+	// validate docker registry path format (<host>[:<port>]/<name>)
+	pathParts := strings.SplitN(d.Config.Path, "/", 2)
+	if len(pathParts) != 2 || pathParts[1] == "" {
+		return nil, fmt.Errorf("invalid docker registry path")
+	}
+	host := pathParts[0]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return nil, fmt.Errorf("invalid docker registry path")
+	}
+
+	return &DockerRegistryPath{
+		Host: host,
+		Repo: pathParts[1],
+	}, nil
+}
+
+// newClient creates v2Client, or returns the client already created which has the bearer token
+func (d *DockerRegistry) newClient(path *DockerRegistryPath, log logr.Logger) *v2Client {
+	if d.client == nil {
+		d.client = newV2Client(path.Host, path.Repo, d.Config.BasicCred, d.Config.PlainHTTP, log)
+	}
+	return d.client
+}
+
+// GetTags filters and gets newer tags than the specified current tag
+func (d *DockerRegistry) GetTags(currentTag string) ([]version.ImageVersion, error) {
+
+	path, err := d.parseRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	c := d.Config
+	log := c.Log.WithValues("image_repo", path.Repo)
+	log.V(1).Info("GetTags", "host", path.Host, "repo", path.Repo)
+
+	client := d.newClient(path, log)
+	tags, err := client.listTags()
+	if err != nil {
+		return nil, err
+	}
+	if c.TagFormat == version.TagFormatTimestamp {
+		if err := client.fetchCreated(tags); err != nil {
+			return nil, err
+		}
+	}
+
+	// filter tags
+	imageVers := filterTags(c, log, tags, currentTag)
+	return imageVers, nil
+}
+
+// GetDigest gets the manifest digest of the specified tag
+func (d *DockerRegistry) GetDigest(tag string) (string, error) {
+
+	path, err := d.parseRegistryPath()
+	if err != nil {
+		return "", err
+	}
+
+	c := d.Config
+	log := c.Log.WithValues("image_repo", path.Repo)
+	log.V(1).Info("GetDigest", "host", path.Host, "repo", path.Repo, "tag", tag)
+
+	client := d.newClient(path, log)
+	return client.manifestDigest(tag)
+}
@@ -0,0 +1,177 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kazylla/gitops-controller/controllers/version"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// newTestRegistry starts an in-process registry which serves the tags by pages of 2 tags
+func newTestRegistry(t *testing.T, auth string, tags []string) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "user" || password != "pass" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("scope") != "repository:foo/bar:pull" {
+				t.Errorf("unexpected scope: %s", r.URL.Query().Get("scope"))
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "test-token"})
+			return
+		case "/v2/foo/bar/tags/list":
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		// authenticate
+		switch auth {
+		case "bearer":
+			if r.Header.Get("Authorization") != "Bearer test-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:foo/bar:pull"`, srv.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		case "basic":
+			username, password, ok := r.BasicAuth()
+			if !ok || username != "user" || password != "pass" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		// paginate
+		start := 0
+		if last := r.URL.Query().Get("last"); last != "" {
+			for i, tag := range tags {
+				if tag == last {
+					start = i + 1
+				}
+			}
+		}
+		end := start + 2
+		if end >= len(tags) {
+			end = len(tags)
+		} else {
+			w.Header().Set("Link", fmt.Sprintf(`</v2/foo/bar/tags/list?n=2&last=%s>; rel="next"`, tags[end-1]))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"name": "foo/bar",
+			"tags": tags[start:end],
+		})
+	}))
+	return srv
+}
+
+func TestDockerRegistry_GetTags(t *testing.T) {
+	registryTags := []string{"v1.0.0", "v1.1.0", "latest", "v1.2.0", "v2.0.0", "v2.1.0-rc.1"}
+	tests := []struct {
+		name       string
+		auth       string
+		cred       BasicCred
+		currentTag string
+		result     []string
+		err        bool
+	}{
+		{
+			"anonymous",
+			"",
+			BasicCred{},
+			"",
+			[]string{"v1.0.0", "v1.1.0", "v1.2.0", "v2.0.0"},
+			false,
+		},
+		{
+			"bearer token",
+			"bearer",
+			BasicCred{Username: "user", Password: "pass"},
+			"v1.1.0",
+			[]string{"v1.2.0", "v2.0.0"},
+			false,
+		},
+		{
+			"basic auth",
+			"basic",
+			BasicCred{Username: "user", Password: "pass"},
+			"v1.2.0",
+			[]string{"v2.0.0"},
+			false,
+		},
+		{
+			"bearer token with invalid credentials",
+			"bearer",
+			BasicCred{Username: "user", Password: "invalid"},
+			"",
+			nil,
+			true,
+		},
+		{
+			"basic auth without credentials",
+			"basic",
+			BasicCred{},
+			"",
+			nil,
+			true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newTestRegistry(t, test.auth, registryTags)
+			defer srv.Close()
+
+			reg := NewRegistry(Config{
+				Type:      RegDocker,
+				Path:      strings.TrimPrefix(srv.URL, "http://") + "/foo/bar",
+				TagFormat: version.TagFormatSemantic,
+				Log:       log.NullLogger{},
+				BasicCred: test.cred,
+				PlainHTTP: true,
+			})
+			imageVers, err := reg.GetTags(test.currentTag)
+			if test.err {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			result := make([]string, 0)
+			for _, v := range imageVers {
+				result = append(result, v.GetTag())
+			}
+			if strings.Join(result, ",") != strings.Join(test.result, ",") {
+				t.Errorf("expected %v, got %v", test.result, result)
+			}
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:samalba/my-app:pull,push"`)
+	if scheme != "Bearer" {
+		t.Errorf("expected Bearer, got %s", scheme)
+	}
+	expected := map[string]string{
+		"realm":   "https://auth.docker.io/token",
+		"service": "registry.docker.io",
+		"scope":   "repository:samalba/my-app:pull,push",
+	}
+	for k, v := range expected {
+		if params[k] != v {
+			t.Errorf("expected %s=%s, got %s", k, v, params[k])
+		}
+	}
+}
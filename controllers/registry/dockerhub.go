@@ -0,0 +1,153 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/kazylla/gitops-controller/controllers/version"
+)
+
+const (
+	dockerHubHost = "registry-1.docker.io"
+
+	// dockerHubRateLimitThreshold is the number of remaining requests at which the registry backs off
+	dockerHubRateLimitThreshold = 10
+)
+
+type DockerHubRegistry struct {
+	Config Config
+
+	// client is reused by GetTags and GetDigest, which keeps the bearer token and the rate limit
+	client *v2Client
+}
+
+type DockerHubRegistryPath struct {
+	Repo string
+}
+
+// parseRegistryPath parses Docker Hub registry path
+func (d *DockerHubRegistry) parseRegistryPath() (*DockerHubRegistryPath, error) {
+	// validate docker hub registry path format ([docker.io/]<namespace>/<name>, or [docker.io/]<name> for official images)
+	path := d.Config.Path
+	for _, host := range []string{"docker.io/", "index.docker.io/", "registry-1.docker.io/"} {
+		path = strings.TrimPrefix(path, host)
+	}
+	pathParts := strings.Split(path, "/")
+	for _, p := range pathParts {
+		if p == "" {
+			return nil, fmt.Errorf("invalid docker hub registry path")
+		}
+	}
+	switch {
+	case len(pathParts) == 1:
+		path = "library/" + path
+	case strings.ContainsAny(pathParts[0], ".:"):
+		return nil, fmt.Errorf("invalid docker hub registry path")
+	}
+
+	return &DockerHubRegistryPath{
+		Repo: path,
+	}, nil
+}
+
+// parseRateLimit parses the rate limit header (e.g. "100;w=21600") into the count and the window
+func parseRateLimit(header string) (int, time.Duration, error) {
+	parts := strings.Split(header, ";")
+	count, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, err
+	}
+	var window time.Duration
+	for _, p := range parts[1:] {
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "w=") {
+			seconds, err := strconv.Atoi(strings.TrimPrefix(p, "w="))
+			if err != nil {
+				return 0, 0, err
+			}
+			window = time.Duration(seconds) * time.Second
+		}
+	}
+	return count, window, nil
+}
+
+// checkRateLimit returns RateLimitError if the remaining pulls reported by Docker Hub are near the limit
+func checkRateLimit(resp *http.Response) *RateLimitError {
+	remainingHeader := resp.Header.Get("RateLimit-Remaining")
+	if remainingHeader == "" {
+		return nil
+	}
+	remaining, window, err := parseRateLimit(remainingHeader)
+	if err != nil || remaining > dockerHubRateLimitThreshold {
+		return nil
+	}
+
+	// wait until enough requests are regained within the rolling window
+	retryAfter := window
+	limit, _, err := parseRateLimit(resp.Header.Get("RateLimit-Limit"))
+	if err == nil && limit > 0 && window > 0 {
+		retryAfter = window * time.Duration(dockerHubRateLimitThreshold-remaining+1) / time.Duration(limit)
+	}
+	return &RateLimitError{
+		Remaining:  remaining,
+		RetryAfter: retryAfter,
+	}
+}
+
+// newClient creates v2Client checking the rate limit, or returns the client already created
+func (d *DockerHubRegistry) newClient(path *DockerHubRegistryPath, log logr.Logger) *v2Client {
+	if d.client == nil {
+		d.client = newV2Client(dockerHubHost, path.Repo, d.Config.BasicCred, false, log)
+		d.client.checkRateLimit = checkRateLimit
+	}
+	return d.client
+}
+
+// GetTags filters and gets newer tags than the specified current tag
+func (d *DockerHubRegistry) GetTags(currentTag string) ([]version.ImageVersion, error) {
+
+	path, err := d.parseRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	c := d.Config
+	log := c.Log.WithValues("image_repo", path.Repo)
+	log.V(1).Info("GetTags", "repo", path.Repo)
+
+	client := d.newClient(path, log)
+	tags, err := client.listTags()
+	if err != nil {
+		return nil, err
+	}
+	if c.TagFormat == version.TagFormatTimestamp {
+		if err := client.fetchCreated(tags); err != nil {
+			return nil, err
+		}
+	}
+
+	// filter tags
+	imageVers := filterTags(c, log, tags, currentTag)
+	return imageVers, nil
+}
+
+// GetDigest gets the manifest digest of the specified tag
+// (HEAD requests for the manifest are not counted by the Docker Hub rate limit)
+func (d *DockerHubRegistry) GetDigest(tag string) (string, error) {
+
+	path, err := d.parseRegistryPath()
+	if err != nil {
+		return "", err
+	}
+
+	c := d.Config
+	log := c.Log.WithValues("image_repo", path.Repo)
+	log.V(1).Info("GetDigest", "repo", path.Repo, "tag", tag)
+
+	client := d.newClient(path, log)
+	return client.manifestDigest(tag)
+}
@@ -0,0 +1,184 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestDockerHubRegistry_parseRegistryPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		repo string
+		err  bool
+	}{
+		{
+			"official image",
+			"nginx",
+			"library/nginx",
+			false,
+		},
+		{
+			"official image with host",
+			"docker.io/nginx",
+			"library/nginx",
+			false,
+		},
+		{
+			"user image",
+			"docker.io/bitnami/redis",
+			"bitnami/redis",
+			false,
+		},
+		{
+			"other registry",
+			"ghcr.io/foo/bar",
+			"",
+			true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := &DockerHubRegistry{Config: Config{Path: test.path}}
+			path, err := d.parseRegistryPath()
+			if test.err {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if path.Repo != test.repo {
+				t.Errorf("expected %s, got %s", test.repo, path.Repo)
+			}
+		})
+	}
+}
+
+func TestCheckRateLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		limit      string
+		remaining  string
+		retryAfter time.Duration
+		err        bool
+	}{
+		{
+			"no rate limit headers",
+			"",
+			"",
+			0,
+			false,
+		},
+		{
+			"enough remaining",
+			"100;w=21600",
+			"76;w=21600",
+			0,
+			false,
+		},
+		{
+			"near the limit",
+			"100;w=21600",
+			"5;w=21600",
+			6 * 216 * time.Second,
+			true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			if test.limit != "" {
+				resp.Header.Set("RateLimit-Limit", test.limit)
+				resp.Header.Set("RateLimit-Remaining", test.remaining)
+			}
+			rateLimitErr := checkRateLimit(resp)
+			if !test.err {
+				if rateLimitErr != nil {
+					t.Errorf("got unexpected error: %s", rateLimitErr.Error())
+				}
+				return
+			}
+			if rateLimitErr == nil {
+				t.Fatalf("expected RateLimitError, got nil")
+			}
+			if rateLimitErr.RetryAfter != test.retryAfter {
+				t.Errorf("expected %s, got %s", test.retryAfter, rateLimitErr.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestV2Client_TooManyRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client := newV2Client(strings.TrimPrefix(srv.URL, "http://"), "library/nginx", BasicCred{}, true, log.NullLogger{})
+	_, err := client.listTags()
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected RateLimitError, got %v", err)
+	}
+	if rateLimitErr.RetryAfter != time.Minute {
+		t.Errorf("expected %s, got %s", time.Minute, rateLimitErr.RetryAfter)
+	}
+}
+
+func TestV2Client_RateLimitWarning(t *testing.T) {
+	pulls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Limit", "100;w=21600")
+		w.Header().Set("RateLimit-Remaining", "5;w=21600")
+		switch {
+		case r.URL.Path == "/v2/library/nginx/tags/list":
+			fmt.Fprint(w, `{"name":"library/nginx","tags":["1.0","1.1"]}`)
+		case strings.HasPrefix(r.URL.Path, "/v2/library/nginx/manifests/"):
+			w.Header().Set("Docker-Content-Digest", "sha256:"+path.Base(r.URL.Path))
+			if r.Method == http.MethodHead {
+				return
+			}
+			pulls++
+			fmt.Fprint(w, `{"schemaVersion":2,"config":{"digest":"sha256:config1"}}`)
+		case r.URL.Path == "/v2/library/nginx/blobs/sha256:config1":
+			fmt.Fprint(w, `{"created":"2024-05-01T10:00:00Z"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := newV2Client(strings.TrimPrefix(srv.URL, "http://"), "library/nginx", BasicCred{}, true, log.NullLogger{})
+	client.checkRateLimit = checkRateLimit
+
+	// listing tags is not counted by the rate limit
+	tags, err := client.listTags()
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+
+	// the pull which reports the warning succeeds, and the following pull is not sent
+	err = client.fetchCreated(tags)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("expected RateLimitError, got %v", err)
+	}
+	if pulls != 1 {
+		t.Errorf("expected 1 pull, got %d", pulls)
+	}
+	if tags[0].Created.IsZero() {
+		t.Errorf("expected created time of %s, got zero", tags[0].Name)
+	}
+}
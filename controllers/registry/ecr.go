@@ -7,11 +7,15 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/go-logr/logr"
 	"github.com/kazylla/gitops-controller/controllers/version"
 )
 
 type ECRRegistry struct {
 	Config Config
+
+	// svc is created once, and reused by GetTags and GetDigest
+	svc *ecr.ECR
 }
 
 type ECRRegistryPath struct {
@@ -42,20 +46,16 @@ func (e *ECRRegistry) parseRegistryPath() (*ECRRegistryPath, error) {
 	}, nil
 }
 
-// GetTags filters and gets newer tags than the specified current tag
-func (e *ECRRegistry) GetTags(currentTag string) ([]version.ImageVersion, error) {
-
-	path, err := e.parseRegistryPath()
-	if err != nil {
-		return nil, err
+// newService creates ECR service client with the aws session, or returns the client already created
+func (e *ECRRegistry) newService(path *ECRRegistryPath, log logr.Logger) (*ecr.ECR, error) {
+	if e.svc != nil {
+		return e.svc, nil
 	}
-
 	c := e.Config
-	log := c.Log.WithValues("image_repo", path.Repo)
-	log.V(1).Info("GetTags", "account_id", path.AWSAccountID, "region", path.Region, "repo", path.Repo)
 
 	// create aws session
 	var sess *session.Session
+	var err error
 	switch {
 	case c.AWSCred.Profile != "":
 		sess, err = session.NewSessionWithOptions(session.Options{
@@ -70,10 +70,14 @@ func (e *ECRRegistry) GetTags(currentTag string) ([]version.ImageVersion, error)
 	if err != nil {
 		return nil, err
 	}
-	ecrSvc := ecr.New(sess)
+	e.svc = ecr.New(sess)
+	return e.svc, nil
+}
 
+// listImages gets all tags of the repository
+func (e *ECRRegistry) listImages(ecrSvc *ecr.ECR, path *ECRRegistryPath) ([]Tag, error) {
 	var nextToken *string
-	imageVers := make([]version.ImageVersion, 0)
+	tags := make([]Tag, 0)
 	for {
 		// get all tags
 		listImagesInput := ecr.ListImagesInput{
@@ -87,24 +91,12 @@ func (e *ECRRegistry) GetTags(currentTag string) ([]version.ImageVersion, error)
 			return nil, err
 		}
 
-		// filter tags
 		for _, imageId := range listImagesOutput.ImageIds {
-			imageVer, err := version.NewImageVersion(*imageId.ImageTag, c.TagFormat)
-			if err != nil {
+			if imageId.ImageTag == nil {
+				// untagged image
 				continue
 			}
-			if currentTag != "" {
-				result, err := imageVer.Compare(currentTag)
-				if err != nil {
-					// occurs when the current version format changes
-					log.Info(err.Error())
-				}
-				if result <= 0 {
-					continue
-				}
-			}
-			log.V(1).Info("new version found", "tag", imageVer.GetTag())
-			imageVers = append(imageVers, imageVer)
+			tags = append(tags, Tag{Name: *imageId.ImageTag})
 		}
 
 		if listImagesOutput.NextToken == nil {
@@ -112,5 +104,105 @@ func (e *ECRRegistry) GetTags(currentTag string) ([]version.ImageVersion, error)
 		}
 		nextToken = listImagesOutput.NextToken
 	}
+	return tags, nil
+}
+
+// describeImages gets all tags of the repository with their push times
+func (e *ECRRegistry) describeImages(ecrSvc *ecr.ECR, path *ECRRegistryPath) ([]Tag, error) {
+	var nextToken *string
+	tags := make([]Tag, 0)
+	for {
+		describeImagesInput := ecr.DescribeImagesInput{
+			RepositoryName: aws.String(path.Repo),
+			RegistryId:     aws.String(path.AWSAccountID),
+			MaxResults:     aws.Int64(100),
+			NextToken:      nextToken,
+			Filter: &ecr.DescribeImagesFilter{
+				TagStatus: aws.String(ecr.TagStatusTagged),
+			},
+		}
+		describeImagesOutput, err := ecrSvc.DescribeImages(&describeImagesInput)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, detail := range describeImagesOutput.ImageDetails {
+			for _, imageTag := range detail.ImageTags {
+				tags = append(tags, Tag{
+					Name:    aws.StringValue(imageTag),
+					Digest:  aws.StringValue(detail.ImageDigest),
+					Created: aws.TimeValue(detail.ImagePushedAt),
+				})
+			}
+		}
+
+		if describeImagesOutput.NextToken == nil {
+			break
+		}
+		nextToken = describeImagesOutput.NextToken
+	}
+	return tags, nil
+}
+
+// GetTags filters and gets newer tags than the specified current tag
+func (e *ECRRegistry) GetTags(currentTag string) ([]version.ImageVersion, error) {
+
+	path, err := e.parseRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	c := e.Config
+	log := c.Log.WithValues("image_repo", path.Repo)
+	log.V(1).Info("GetTags", "account_id", path.AWSAccountID, "region", path.Region, "repo", path.Repo)
+
+	ecrSvc, err := e.newService(path, log)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []Tag
+	if c.TagFormat == version.TagFormatTimestamp {
+		tags, err = e.describeImages(ecrSvc, path)
+	} else {
+		tags, err = e.listImages(ecrSvc, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// filter tags
+	imageVers := filterTags(c, log, tags, currentTag)
 	return imageVers, nil
 }
+
+// GetDigest gets the manifest digest of the specified tag
+func (e *ECRRegistry) GetDigest(tag string) (string, error) {
+
+	path, err := e.parseRegistryPath()
+	if err != nil {
+		return "", err
+	}
+
+	log := e.Config.Log.WithValues("image_repo", path.Repo)
+	log.V(1).Info("GetDigest", "account_id", path.AWSAccountID, "region", path.Region, "repo", path.Repo, "tag", tag)
+
+	ecrSvc, err := e.newService(path, log)
+	if err != nil {
+		return "", err
+	}
+	describeImagesOutput, err := ecrSvc.DescribeImages(&ecr.DescribeImagesInput{
+		RepositoryName: aws.String(path.Repo),
+		RegistryId:     aws.String(path.AWSAccountID),
+		ImageIds: []*ecr.ImageIdentifier{
+			{ImageTag: aws.String(tag)},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(describeImagesOutput.ImageDetails) == 0 {
+		return "", fmt.Errorf("image not found: %s:%s", path.Repo, tag)
+	}
+	return aws.StringValue(describeImagesOutput.ImageDetails[0].ImageDigest), nil
+}
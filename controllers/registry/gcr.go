@@ -0,0 +1,146 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/kazylla/gitops-controller/controllers/version"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const gcpCloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+type GCRRegistry struct {
+	Config Config
+
+	// client is authenticated once, and reused by GetTags and GetDigest
+	client *v2Client
+}
+
+type GCRRegistryPath struct {
+	Host    string
+	Project string
+	Repo    string
+}
+
+// parseRegistryPath parses GCR or Artifact Registry path
+func (g *GCRRegistry) parseRegistryPath() (*GCRRegistryPath, error) {
+	// validate gcr registry path format
+	// - [<region>.]gcr.io/<project>/<image>
+	// - <location>-docker.pkg.dev/<project>/<repo>/<image>
+	pathParts := strings.Split(g.Config.Path, "/")
+	host := pathParts[0]
+	switch {
+	case host == "gcr.io" || strings.HasSuffix(host, ".gcr.io"):
+		if len(pathParts) < 3 {
+			return nil, fmt.Errorf("invalid gcr registry path")
+		}
+	case strings.HasSuffix(host, "-docker.pkg.dev"):
+		if len(pathParts) < 4 {
+			return nil, fmt.Errorf("invalid artifact registry path")
+		}
+	default:
+		return nil, fmt.Errorf("invalid gcr registry path")
+	}
+	for _, p := range pathParts[1:] {
+		if p == "" {
+			return nil, fmt.Errorf("invalid gcr registry path")
+		}
+	}
+
+	return &GCRRegistryPath{
+		Host:    host,
+		Project: pathParts[1],
+		Repo:    strings.Join(pathParts[1:], "/"),
+	}, nil
+}
+
+// tokenSource creates oauth2.TokenSource from the service account key, or from the application default
+// credentials (e.g. workload identity) if the key is not specified
+func (g *GCRRegistry) tokenSource(ctx context.Context) (oauth2.TokenSource, error) {
+	if len(g.Config.GCPCred.CredentialsJSON) == 0 {
+		return google.DefaultTokenSource(ctx, gcpCloudPlatformScope)
+	}
+	cred, err := google.CredentialsFromJSON(ctx, g.Config.GCPCred.CredentialsJSON, gcpCloudPlatformScope)
+	if err != nil {
+		return nil, err
+	}
+	return cred.TokenSource, nil
+}
+
+// newClient creates v2Client authenticated with the gcp access token, or returns the client already created
+func (g *GCRRegistry) newClient(path *GCRRegistryPath, log logr.Logger) (*v2Client, error) {
+	if g.client != nil {
+		return g.client, nil
+	}
+
+	// get gcp access token
+	ts, err := g.tokenSource(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	token, err := ts.Token()
+	if err != nil {
+		return nil, err
+	}
+	log.V(1).Info("gcp access token created", "service_account_key", len(g.Config.GCPCred.CredentialsJSON) > 0)
+
+	// the registry accepts the access token as the password of "oauth2accesstoken"
+	g.client = newV2Client(path.Host, path.Repo, BasicCred{
+		Username: "oauth2accesstoken",
+		Password: token.AccessToken,
+	}, false, log)
+	return g.client, nil
+}
+
+// GetTags filters and gets newer tags than the specified current tag
+func (g *GCRRegistry) GetTags(currentTag string) ([]version.ImageVersion, error) {
+
+	path, err := g.parseRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	c := g.Config
+	log := c.Log.WithValues("image_repo", path.Repo)
+	log.V(1).Info("GetTags", "host", path.Host, "project", path.Project, "repo", path.Repo)
+
+	client, err := g.newClient(path, log)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := client.listTags()
+	if err != nil {
+		return nil, err
+	}
+	if c.TagFormat == version.TagFormatTimestamp {
+		if err := client.fetchCreated(tags); err != nil {
+			return nil, err
+		}
+	}
+
+	// filter tags
+	imageVers := filterTags(c, log, tags, currentTag)
+	return imageVers, nil
+}
+
+// GetDigest gets the manifest digest of the specified tag
+func (g *GCRRegistry) GetDigest(tag string) (string, error) {
+
+	path, err := g.parseRegistryPath()
+	if err != nil {
+		return "", err
+	}
+
+	log := g.Config.Log.WithValues("image_repo", path.Repo)
+	log.V(1).Info("GetDigest", "host", path.Host, "project", path.Project, "repo", path.Repo, "tag", tag)
+
+	client, err := g.newClient(path, log)
+	if err != nil {
+		return "", err
+	}
+	return client.manifestDigest(tag)
+}
@@ -0,0 +1,75 @@
+package registry
+
+import (
+	"testing"
+)
+
+func TestGCRRegistry_parseRegistryPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		host    string
+		project string
+		repo    string
+		err     bool
+	}{
+		{
+			"container registry",
+			"gcr.io/my-project/my-image",
+			"gcr.io",
+			"my-project",
+			"my-project/my-image",
+			false,
+		},
+		{
+			"regional container registry",
+			"asia.gcr.io/my-project/team/my-image",
+			"asia.gcr.io",
+			"my-project",
+			"my-project/team/my-image",
+			false,
+		},
+		{
+			"artifact registry",
+			"asia-northeast1-docker.pkg.dev/my-project/my-repo/my-image",
+			"asia-northeast1-docker.pkg.dev",
+			"my-project",
+			"my-project/my-repo/my-image",
+			false,
+		},
+		{
+			"artifact registry without repository",
+			"asia-northeast1-docker.pkg.dev/my-project/my-image",
+			"",
+			"",
+			"",
+			true,
+		},
+		{
+			"not a gcr path",
+			"999999999999.dkr.ecr.ap-northeast-1.amazonaws.com/xxx/xxx",
+			"",
+			"",
+			"",
+			true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := &GCRRegistry{Config: Config{Path: test.path}}
+			path, err := g.parseRegistryPath()
+			if test.err {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if path.Host != test.host || path.Project != test.project || path.Repo != test.repo {
+				t.Errorf("expected %s %s %s, got %s %s %s", test.host, test.project, test.repo, path.Host, path.Project, path.Repo)
+			}
+		})
+	}
+}
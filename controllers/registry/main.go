@@ -1,6 +1,9 @@
 package registry
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/go-logr/logr"
 	"github.com/kazylla/gitops-controller/controllers/version"
 )
@@ -9,26 +12,88 @@ type RegType int
 
 const (
 	RegECR RegType = iota
+	RegDocker
+	RegGCR
+	RegACR
+	RegDockerHub
 )
 
 type Registry interface {
 	GetTags(currentTag string) ([]version.ImageVersion, error)
+	GetDigest(tag string) (string, error)
+}
+
+// Tag is an image tag in the registry
+type Tag struct {
+	Name string
+	// Digest is the manifest digest of the tag, empty if unknown
+	Digest string
+	// Created is the creation time of the tag, zero if the registry does not report it
+	Created time.Time
+}
+
+// RateLimitError is returned when the registry rate limit is (nearly) exceeded
+type RateLimitError struct {
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("registry rate limit exceeded (remaining: %d, retry after: %s)", e.Remaining, e.RetryAfter)
 }
 
 type AWSCred struct {
 	Profile string
 }
 
+type GCPCred struct {
+	// CredentialsJSON is the service account key, or empty to use the application default credentials
+	CredentialsJSON []byte
+}
+
+type AzureCred struct {
+	TenantID           string
+	ClientID           string
+	ClientSecret       string
+	FederatedTokenFile string
+	AuthorityHost      string
+}
+
+type BasicCred struct {
+	Username string
+	Password string
+	// Host binds the credentials to the registry host, which are sent neither to the other registries nor to
+	// the token server on another host (empty sends them to the registry of the path and its token server)
+	Host string
+}
+
 type Config struct {
 	Type RegType
 
 	// Common
-	Path      string
-	TagFormat version.TagFormat
-	Log       logr.Logger
+	Path       string
+	TagFormat  version.TagFormat
+	TagOptions version.Options
+	Log        logr.Logger
+	// IncludeCurrentTag also gets the current tag to detect re-pushes by the digest
+	IncludeCurrentTag bool
+
+	// TagFormat=Semantic
+	TagConstraint   *version.Constraint
+	AllowPreRelease bool
 
 	// RegType=ECR
 	AWSCred AWSCred
+
+	// RegType=GCR
+	GCPCred GCPCred
+
+	// RegType=ACR
+	AzureCred AzureCred
+
+	// RegType=Docker,DockerHub
+	BasicCred BasicCred
+	PlainHTTP bool
 }
 
 // NewRegistry creates Registry according to RegType
@@ -38,9 +103,97 @@ func NewRegistry(c Config) Registry {
 		return &ECRRegistry{
 			Config: c,
 		}
+	case RegDocker:
+		return &DockerRegistry{
+			Config: c,
+		}
+	case RegGCR:
+		return &GCRRegistry{
+			Config: c,
+		}
+	case RegACR:
+		return &ACRRegistry{
+			Config: c,
+		}
+	case RegDockerHub:
+		return &DockerHubRegistry{
+			Config: c,
+		}
 	default:
 		return &ECRRegistry{
 			Config: c,
 		}
 	}
 }
+
+// dedupeDigests keeps one of the tags which refer to the same digest (e.g. "latest" and the tag of the build),
+// since they have the same creation time. The current tag is kept, otherwise the greatest name is kept.
+func dedupeDigests(tags []Tag, currentTag string) []Tag {
+	kept := make(map[string]string)
+	for _, tag := range tags {
+		if tag.Digest == "" {
+			continue
+		}
+		name, ok := kept[tag.Digest]
+		if !ok || (name != currentTag && (tag.Name == currentTag || tag.Name > name)) {
+			kept[tag.Digest] = tag.Name
+		}
+	}
+
+	deduped := make([]Tag, 0, len(tags))
+	for _, tag := range tags {
+		if tag.Digest == "" || kept[tag.Digest] == tag.Name {
+			deduped = append(deduped, tag)
+		}
+	}
+	return deduped
+}
+
+// filterTags converts tags to ImageVersion and filters out tags that are not newer than the specified current tag
+func filterTags(c Config, log logr.Logger, tags []Tag, currentTag string) []version.ImageVersion {
+	// timestamp tags are ordered by the creation time reported by the registry
+	if c.TagFormat == version.TagFormatTimestamp {
+		c.TagOptions.PushedAt = make(map[string]time.Time)
+		for _, tag := range tags {
+			if !tag.Created.IsZero() {
+				c.TagOptions.PushedAt[tag.Name] = tag.Created
+			}
+		}
+		tags = dedupeDigests(tags, currentTag)
+	}
+
+	imageVers := make([]version.ImageVersion, 0)
+	for _, tag := range tags {
+		imageVer, err := version.NewImageVersion(tag.Name, c.TagFormat, c.TagOptions)
+		if err != nil {
+			continue
+		}
+		if semVer, ok := imageVer.(*version.SemanticImageVersion); ok {
+			if semVer.IsPreRelease() && !c.AllowPreRelease {
+				log.V(1).Info("pre-release is not allowed", "tag", tag.Name)
+				continue
+			}
+			if c.TagConstraint != nil && !c.TagConstraint.Check(semVer) {
+				log.V(1).Info("tag does not satisfy the constraint", "tag", tag.Name, "constraint", c.TagConstraint.String())
+				continue
+			}
+		}
+		if currentTag != "" {
+			result, err := imageVer.Compare(currentTag)
+			if err != nil {
+				// occurs when the current version format changes
+				log.Info(err.Error())
+			}
+			if result < 0 || (result == 0 && !c.IncludeCurrentTag) {
+				continue
+			}
+		}
+		if tag.Created.IsZero() {
+			log.V(1).Info("new version found", "tag", imageVer.GetTag())
+		} else {
+			log.V(1).Info("new version found", "tag", imageVer.GetTag(), "created", tag.Created)
+		}
+		imageVers = append(imageVers, imageVer)
+	}
+	return imageVers
+}
@@ -0,0 +1,43 @@
+package registry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDedupeDigests(t *testing.T) {
+	tags := []Tag{
+		{Name: "a1b2c3d", Digest: "sha256:1111"},
+		{Name: "latest", Digest: "sha256:2222"},
+		{Name: "e4f5a6b", Digest: "sha256:2222"},
+		{Name: "stable", Digest: "sha256:1111"},
+		{Name: "unknown"},
+	}
+	tests := []struct {
+		name       string
+		currentTag string
+		result     []string
+	}{
+		{
+			"greatest name is kept",
+			"",
+			[]string{"latest", "stable", "unknown"},
+		},
+		{
+			"current tag is kept",
+			"a1b2c3d",
+			[]string{"a1b2c3d", "latest", "unknown"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := make([]string, 0)
+			for _, tag := range dedupeDigests(tags, test.currentTag) {
+				result = append(result, tag.Name)
+			}
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("expected %v, got %v", test.result, result)
+			}
+		})
+	}
+}
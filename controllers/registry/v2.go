@@ -0,0 +1,448 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// manifestMediaTypes are the manifest media types to accept, including the multi-platform index
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+// v2Client is a minimal Docker Registry HTTP API v2 (OCI Distribution) client
+type v2Client struct {
+	baseURL *url.URL
+	repo    string
+	cred    BasicCred
+	client  *http.Client
+	log     logr.Logger
+
+	// checkRateLimit inspects the responses of the pulls, and returns the warning if the rate limit is near
+	checkRateLimit func(*http.Response) *RateLimitError
+	// rateLimit is the last warning, which stops the further pulls until the next reconcile
+	rateLimit *RateLimitError
+
+	// authorization state decided by the authentication challenge
+	basic bool
+	token string
+	// scope is the scope of the challenge for which the token has been issued
+	scope string
+}
+
+// newV2Client creates v2Client for the specified registry host and repository
+func newV2Client(host, repo string, cred BasicCred, plainHTTP bool, log logr.Logger) *v2Client {
+	scheme := "https"
+	if plainHTTP {
+		scheme = "http"
+	}
+	if cred.Host != "" && cred.Host != host {
+		cred = BasicCred{}
+	}
+	return &v2Client{
+		baseURL: &url.URL{Scheme: scheme, Host: host},
+		repo:    repo,
+		cred:    cred,
+		client:  http.DefaultClient,
+		log:     log,
+	}
+}
+
+// authorize sets the credentials obtained from the authentication challenge to the request
+func (v *v2Client) authorize(req *http.Request) {
+	switch {
+	case v.token != "":
+		req.Header.Set("Authorization", "Bearer "+v.token)
+	case v.basic:
+		req.SetBasicAuth(v.cred.Username, v.cred.Password)
+	}
+}
+
+// isPull reports whether the request gets the manifest, which is counted by the rate limit such as Docker Hub
+func isPull(req *http.Request) bool {
+	return req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/manifests/")
+}
+
+// send sends the request, and returns RateLimitError if the registry rejects it by the rate limit,
+// or if the pull is requested after the rate limit has been reported to be near
+func (v *v2Client) send(req *http.Request) (*http.Response, error) {
+	if v.rateLimit != nil && isPull(req) {
+		return nil, v.rateLimit
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		_ = resp.Body.Close()
+		rateLimitErr := &RateLimitError{}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			rateLimitErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, rateLimitErr
+	}
+	// the response is still returned with the warning, which stops only the following pulls
+	if v.checkRateLimit != nil && isPull(req) {
+		if rateLimit := v.checkRateLimit(resp); rateLimit != nil {
+			v.log.Info("registry rate limit is near", "remaining", rateLimit.Remaining, "retry_after", rateLimit.RetryAfter)
+			v.rateLimit = rateLimit
+		}
+	}
+	return resp, nil
+}
+
+// do sends the request, and retries once after answering the authentication challenge if the registry requires it,
+// or if the registry requires the token of another scope (e.g. pull after metadata_read of ACR)
+func (v *v2Client) do(req *http.Request) (*http.Response, error) {
+	v.authorize(req)
+	resp, err := v.send(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized || v.basic {
+		return resp, nil
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if v.token != "" {
+		scheme, params := parseChallenge(challenge)
+		if !strings.EqualFold(scheme, "bearer") || params["scope"] == "" || params["scope"] == v.scope {
+			return resp, nil
+		}
+	}
+	_ = resp.Body.Close()
+	if err := v.authenticate(challenge); err != nil {
+		return nil, err
+	}
+
+	retryReq := req.Clone(req.Context())
+	v.authorize(retryReq)
+	return v.send(retryReq)
+}
+
+// authenticate answers the WWW-Authenticate challenge with basic auth or a bearer token
+func (v *v2Client) authenticate(challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if v.cred.Username == "" {
+			return fmt.Errorf("registry requires basic auth, but no credentials are configured")
+		}
+		v.basic = true
+		v.log.V(1).Info("basic auth is required")
+		return nil
+	case "bearer":
+		token, err := v.fetchToken(params)
+		if err != nil {
+			return err
+		}
+		v.token = token
+		v.scope = params["scope"]
+		v.log.V(1).Info("bearer token has been issued", "realm", params["realm"], "scope", params["scope"])
+		return nil
+	default:
+		return fmt.Errorf("unsupported authentication challenge: %s", challenge)
+	}
+}
+
+// fetchToken gets a bearer token from the token server specified in the challenge
+func (v *v2Client) fetchToken(params map[string]string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid bearer realm: %s", params["realm"])
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", v.repo)
+	}
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	// the credentials bound to the registry host are not sent to the token server on another host
+	if v.cred.Username != "" && (v.cred.Host == "" || v.cred.Host == realm.Host) {
+		req.SetBasicAuth(v.cred.Username, v.cred.Password)
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to get bearer token: %s", resp.Status)
+	}
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", err
+	}
+	if tokenResp.Token != "" {
+		return tokenResp.Token, nil
+	}
+	if tokenResp.AccessToken != "" {
+		return tokenResp.AccessToken, nil
+	}
+	return "", fmt.Errorf("token server returned no token")
+}
+
+// getJSON gets the JSON document into out, and returns the URL of the next page if there is a Link header
+func (v *v2Client) getJSON(u *url.URL, out interface{}, accept ...string) (*url.URL, error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	resp, err := v.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unable to get %s: %s: %s", u.Path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, err
+	}
+	return nextLink(u, resp.Header.Get("Link"))
+}
+
+// listTags gets all tags of the repository following the Link header pagination
+func (v *v2Client) listTags() ([]Tag, error) {
+	next := v.baseURL.ResolveReference(&url.URL{
+		Path:     fmt.Sprintf("/v2/%s/tags/list", v.repo),
+		RawQuery: "n=100",
+	})
+
+	tags := make([]Tag, 0)
+	for next != nil {
+		var tagList struct {
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		}
+		var err error
+		next, err = v.getJSON(next, &tagList)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range tagList.Tags {
+			tags = append(tags, Tag{Name: name})
+		}
+	}
+	return tags, nil
+}
+
+// manifestDigest gets the digest of the manifest (or the multi-platform index) of the specified tag
+func (v *v2Client) manifestDigest(tag string) (string, error) {
+	u := v.baseURL.ResolveReference(&url.URL{
+		Path: fmt.Sprintf("/v2/%s/manifests/%s", v.repo, tag),
+	})
+	req, err := http.NewRequest(http.MethodHead, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	resp, err := v.do(req)
+	if err != nil {
+		return "", err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to get %s: %s", u.Path, resp.Status)
+	}
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("no digest for tag: %s", tag)
+	}
+	return digest, nil
+}
+
+// imageCreated gets the created time in the image config of the specified tag or digest
+func (v *v2Client) imageCreated(reference string) (time.Time, error) {
+	var manifest struct {
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+		Manifests []struct {
+			Digest   string `json:"digest"`
+			Platform struct {
+				Architecture string `json:"architecture"`
+				OS           string `json:"os"`
+			} `json:"platform"`
+		} `json:"manifests"`
+	}
+	_, err := v.getJSON(v.baseURL.ResolveReference(&url.URL{
+		Path: fmt.Sprintf("/v2/%s/manifests/%s", v.repo, reference),
+	}), &manifest, manifestMediaTypes...)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// multi-platform index refers to the manifest of each platform (prefer linux/amd64)
+	if len(manifest.Manifests) > 0 && !strings.HasPrefix(reference, "sha256:") {
+		digest := manifest.Manifests[0].Digest
+		for _, m := range manifest.Manifests {
+			if m.Platform.OS == "linux" && m.Platform.Architecture == "amd64" {
+				digest = m.Digest
+				break
+			}
+		}
+		return v.imageCreated(digest)
+	}
+	if manifest.Config.Digest == "" {
+		return time.Time{}, fmt.Errorf("no image config in manifest: %s", reference)
+	}
+
+	var config struct {
+		Created time.Time `json:"created"`
+	}
+	_, err = v.getJSON(v.baseURL.ResolveReference(&url.URL{
+		Path: fmt.Sprintf("/v2/%s/blobs/%s", v.repo, manifest.Config.Digest),
+	}), &config)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return config.Created, nil
+}
+
+// createdTimes caches the created time of the manifest digests by the repository across reconciles,
+// so that only the images of new digests are pulled to get the created time
+var createdTimes = struct {
+	sync.Mutex
+	repos map[string]map[string]time.Time
+}{repos: make(map[string]map[string]time.Time)}
+
+// fetchCreated sets the manifest digest and the created time in the image config to each tag
+// (HEAD requests for the digests are not counted by the rate limit such as Docker Hub)
+func (v *v2Client) fetchCreated(tags []Tag) error {
+	key := v.baseURL.Host + "/" + v.repo
+	createdTimes.Lock()
+	cached := createdTimes.repos[key]
+	createdTimes.Unlock()
+
+	// cache only the current digests, which drops the digests of the deleted tags
+	created := make(map[string]time.Time)
+	defer func() {
+		createdTimes.Lock()
+		createdTimes.repos[key] = created
+		createdTimes.Unlock()
+	}()
+
+	for i := range tags {
+		digest, err := v.manifestDigest(tags[i].Name)
+		if err != nil {
+			if _, ok := err.(*RateLimitError); ok {
+				return err
+			}
+			v.log.Info("unable to get digest", "tag", tags[i].Name, "error", err.Error())
+			continue
+		}
+		tags[i].Digest = digest
+		if t, ok := cached[digest]; ok {
+			tags[i].Created = t
+			created[digest] = t
+			continue
+		}
+
+		t, err := v.imageCreated(tags[i].Name)
+		if err != nil {
+			if _, ok := err.(*RateLimitError); ok {
+				return err
+			}
+			v.log.Info("unable to get created time", "tag", tags[i].Name, "error", err.Error())
+			continue
+		}
+		tags[i].Created = t
+		created[digest] = t
+	}
+	return nil
+}
+
+// nextLink returns the URL of rel="next" in the Link header, or nil if there is no next page
+func nextLink(current *url.URL, link string) (*url.URL, error) {
+	for _, l := range strings.Split(link, ",") {
+		parts := strings.Split(l, ";")
+		target := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, p := range parts[1:] {
+			p = strings.Replace(strings.TrimSpace(p), " ", "", -1)
+			if p != `rel="next"` && p != "rel=next" {
+				continue
+			}
+			u, err := url.Parse(strings.Trim(target, "<>"))
+			if err != nil {
+				return nil, err
+			}
+			return current.ResolveReference(u), nil
+		}
+	}
+	return nil, nil
+}
+
+// parseChallenge parses the WWW-Authenticate header into the scheme and its parameters
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := make(map[string]string)
+	parts := strings.SplitN(strings.TrimSpace(challenge), " ", 2)
+	if len(parts) < 2 {
+		return parts[0], params
+	}
+
+	rest := parts[1]
+	for rest != "" {
+		// key
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = strings.TrimSpace(rest[eq+1:])
+
+		// value (quoted values may contain commas)
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else {
+			end := strings.Index(rest, ",")
+			if end < 0 {
+				value, rest = rest, ""
+			} else {
+				value, rest = rest[:end], rest[end:]
+			}
+		}
+		params[key] = strings.TrimSpace(value)
+		rest = strings.TrimLeft(strings.TrimSpace(rest), ",")
+		rest = strings.TrimSpace(rest)
+	}
+	return parts[0], params
+}
@@ -0,0 +1,231 @@
+package registry
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+func TestV2Client_fetchCreated(t *testing.T) {
+	pulls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/manifests/") {
+			if r.Method == http.MethodHead {
+				w.Header().Set("Docker-Content-Digest", "sha256:"+path.Base(r.URL.Path))
+			} else {
+				pulls++
+			}
+		}
+		switch r.URL.Path {
+		case "/v2/foo/bar/manifests/single":
+			if !strings.Contains(r.Header.Get("Accept"), "application/vnd.oci.image.manifest.v1+json") {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			fmt.Fprint(w, `{"schemaVersion":2,"config":{"digest":"sha256:config1"}}`)
+		case "/v2/foo/bar/manifests/multi":
+			fmt.Fprint(w, `{"schemaVersion":2,"manifests":[`+
+				`{"digest":"sha256:arm64","platform":{"architecture":"arm64","os":"linux"}},`+
+				`{"digest":"sha256:amd64","platform":{"architecture":"amd64","os":"linux"}}]}`)
+		case "/v2/foo/bar/manifests/sha256:amd64":
+			fmt.Fprint(w, `{"schemaVersion":2,"config":{"digest":"sha256:config2"}}`)
+		case "/v2/foo/bar/blobs/sha256:config1":
+			fmt.Fprint(w, `{"created":"2024-05-01T10:00:00Z"}`)
+		case "/v2/foo/bar/blobs/sha256:config2":
+			fmt.Fprint(w, `{"created":"2024-05-02T10:00:00Z"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := newV2Client(strings.TrimPrefix(srv.URL, "http://"), "foo/bar", BasicCred{}, true, log.NullLogger{})
+	tags := []Tag{{Name: "single"}, {Name: "multi"}, {Name: "missing"}}
+	if err := client.fetchCreated(tags); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	expected := []time.Time{
+		time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
+		{},
+	}
+	for i := range expected {
+		if !tags[i].Created.Equal(expected[i]) {
+			t.Errorf("%s: expected %s, got %s", tags[i].Name, expected[i], tags[i].Created)
+		}
+	}
+
+	// the created times of the known digests are not pulled again
+	pulls = 0
+	client = newV2Client(strings.TrimPrefix(srv.URL, "http://"), "foo/bar", BasicCred{}, true, log.NullLogger{})
+	tags = []Tag{{Name: "single"}, {Name: "multi"}}
+	if err := client.fetchCreated(tags); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if pulls != 0 {
+		t.Errorf("expected no pull, got %d", pulls)
+	}
+	for i := range tags {
+		if !tags[i].Created.Equal(expected[i]) {
+			t.Errorf("%s: expected %s, got %s", tags[i].Name, expected[i], tags[i].Created)
+		}
+	}
+}
+
+func TestV2Client_manifestDigest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead || r.URL.Path != "/v2/foo/bar/manifests/latest" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Docker-Content-Digest", "sha256:1111")
+	}))
+	defer srv.Close()
+
+	client := newV2Client(strings.TrimPrefix(srv.URL, "http://"), "foo/bar", BasicCred{}, true, log.NullLogger{})
+	digest, err := client.manifestDigest("latest")
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if digest != "sha256:1111" {
+		t.Errorf("expected sha256:1111, got %s", digest)
+	}
+	if _, err := client.manifestDigest("missing"); err == nil {
+		t.Errorf("expected error, got nil")
+	}
+}
+
+func TestV2Client_boundCred(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      string
+		realmHost bool
+		sent      bool
+	}{
+		{
+			"unbound credentials to the token server on another host",
+			"",
+			false,
+			true,
+		},
+		{
+			"credentials bound to the registry",
+			"registry",
+			true,
+			true,
+		},
+		{
+			"credentials bound to the registry to the token server on another host",
+			"registry",
+			false,
+			false,
+		},
+		{
+			"credentials bound to another registry",
+			"other.example.com",
+			true,
+			false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sent := false
+			tokenHandler := func(w http.ResponseWriter, r *http.Request) {
+				_, _, sent = r.BasicAuth()
+				fmt.Fprint(w, `{"token":"test-token"}`)
+			}
+			authSrv := httptest.NewServer(http.HandlerFunc(tokenHandler))
+			defer authSrv.Close()
+
+			var srv *httptest.Server
+			srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/token" {
+					tokenHandler(w, r)
+					return
+				}
+				if r.Header.Get("Authorization") != "Bearer test-token" {
+					realm := authSrv.URL
+					if test.realmHost {
+						realm = srv.URL
+					}
+					w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test"`, realm))
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				fmt.Fprint(w, `{"name":"foo/bar","tags":["v1.0.0"]}`)
+			}))
+			defer srv.Close()
+
+			host := strings.TrimPrefix(srv.URL, "http://")
+			cred := BasicCred{Username: "user", Password: "pass", Host: test.host}
+			if cred.Host == "registry" {
+				cred.Host = host
+			}
+			client := newV2Client(host, "foo/bar", cred, true, log.NullLogger{})
+			if _, err := client.listTags(); err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if sent != test.sent {
+				t.Errorf("expected %t, got %t", test.sent, sent)
+			}
+		})
+	}
+}
+
+func TestV2Client_scope(t *testing.T) {
+	tokens := 0
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the token is issued for the requested scope only
+		if r.URL.Path == "/token" {
+			tokens++
+			fmt.Fprintf(w, `{"access_token":"token:%s"}`, r.URL.Query().Get("scope"))
+			return
+		}
+		scope := "repository:foo/bar:pull"
+		if strings.HasSuffix(r.URL.Path, "/_tags") {
+			scope = "repository:foo/bar:metadata_read"
+		}
+		if r.Header.Get("Authorization") != "Bearer token:"+scope {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="%s"`, srv.URL, scope))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/acr/v1/foo/bar/_tags":
+			fmt.Fprint(w, `{"tags":[]}`)
+		case "/v2/foo/bar/manifests/latest":
+			w.Header().Set("Docker-Content-Digest", "sha256:1111")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := newV2Client(strings.TrimPrefix(srv.URL, "http://"), "foo/bar", BasicCred{}, true, log.NullLogger{})
+	var tagList struct{}
+	if _, err := client.getJSON(client.baseURL.ResolveReference(&url.URL{Path: "/acr/v1/foo/bar/_tags"}), &tagList); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	digest, err := client.manifestDigest("latest")
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if digest != "sha256:1111" {
+		t.Errorf("expected sha256:1111, got %s", digest)
+	}
+	// the token of the same scope is reused
+	if _, err := client.manifestDigest("latest"); err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if tokens != 2 {
+		t.Errorf("expected 2 tokens, got %d", tokens)
+	}
+}
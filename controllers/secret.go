@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kazylla/gitops-controller/controllers/git"
+	"github.com/kazylla/gitops-controller/controllers/registry"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	gitopsv1 "github.com/kazylla/gitops-controller/api/v1"
+)
+
+// gitCredentials are the credentials for the git repository, either Username and Password, SSHKey or GitHubApp
+type gitCredentials struct {
+	Username string
+	Password string
+	// TokenAuth is true if Password is the token, which is sent to the GitHub API as the token
+	TokenAuth bool
+	SSHKey    *git.SSHKey
+	GitHubApp *git.GitHubApp
+}
+
+// readCredentials reads the git credentials from the secret
+func (r *GitOpsReconciler) readCredentials(ctx context.Context, namespace, name string) (*gitCredentials, error) {
+	var secret corev1.Secret
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, &secret); err != nil {
+		return nil, err
+	}
+	return credentialsFromSecret(&secret)
+}
+
+// credentialsFromSecret converts the secret into the git credentials.
+// The secret is kubernetes.io/ssh-auth ("ssh-privatekey" and "known_hosts", and "token" to create the PR), kubernetes.io/basic-auth
+// ("username" and "password"), or has "token" (and optionally "username"),
+// or "app_id", "installation_id" and "private_key" of the GitHub App.
+func credentialsFromSecret(secret *corev1.Secret) (*gitCredentials, error) {
+	data := secret.Data
+	switch {
+	case len(data["app_id"]) > 0:
+		appID, err := strconv.ParseInt(strings.TrimSpace(string(data["app_id"])), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s has invalid app_id: %s", secret.Name, err.Error())
+		}
+		installationID, err := strconv.ParseInt(strings.TrimSpace(string(data["installation_id"])), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("secret %s has invalid installation_id: %s", secret.Name, err.Error())
+		}
+		if len(data["private_key"]) == 0 {
+			return nil, fmt.Errorf("secret %s has no key private_key", secret.Name)
+		}
+		return &gitCredentials{
+			GitHubApp: &git.GitHubApp{
+				AppID:          appID,
+				InstallationID: installationID,
+				PrivateKey:     data["private_key"],
+			},
+		}, nil
+	case secret.Type == corev1.SecretTypeSSHAuth || len(data[corev1.SSHAuthPrivateKey]) > 0:
+		if len(data[corev1.SSHAuthPrivateKey]) == 0 {
+			return nil, fmt.Errorf("secret %s has no key %s", secret.Name, corev1.SSHAuthPrivateKey)
+		}
+		credentials := &gitCredentials{
+			SSHKey: &git.SSHKey{
+				PrivateKey: data[corev1.SSHAuthPrivateKey],
+				Passphrase: data["passphrase"],
+				KnownHosts: data["known_hosts"],
+			},
+		}
+		// the PR is created by the API, which does not accept the SSH key
+		if len(data["token"]) > 0 {
+			credentials.Username = string(data[corev1.BasicAuthUsernameKey])
+			if credentials.Username == "" {
+				credentials.Username = "git"
+			}
+			credentials.Password = string(data["token"])
+			credentials.TokenAuth = true
+		}
+		return credentials, nil
+	case len(data["token"]) > 0:
+		// the username is not checked by most git hosts for the token
+		username := string(data[corev1.BasicAuthUsernameKey])
+		if username == "" {
+			username = "git"
+		}
+		return &gitCredentials{
+			Username:  username,
+			Password:  string(data["token"]),
+			TokenAuth: true,
+		}, nil
+	case secret.Type == corev1.SecretTypeBasicAuth || len(data[corev1.BasicAuthPasswordKey]) > 0:
+		username := string(data[corev1.BasicAuthUsernameKey])
+		password := string(data[corev1.BasicAuthPasswordKey])
+		if username == "" || password == "" {
+			return nil, fmt.Errorf("secret %s requires both %s and %s", secret.Name, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+		}
+		return &gitCredentials{
+			Username: username,
+			Password: password,
+		}, nil
+	}
+	return nil, fmt.Errorf("secret %s has no git credentials", secret.Name)
+}
+
+// readSSHKey reads the private key, known_hosts and the passphrase from the secret
+func (r *GitOpsReconciler) readSSHKey(ctx context.Context, namespace string, spec *gitopsv1.GitSSHKey) (*git.SSHKey, error) {
+	var secret corev1.Secret
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: spec.SecretName}, &secret); err != nil {
+		return nil, err
+	}
+
+	key := spec.Key
+	if key == "" {
+		key = corev1.SSHAuthPrivateKey
+	}
+	knownHostsKey := spec.KnownHostsKey
+	if knownHostsKey == "" {
+		knownHostsKey = "known_hosts"
+	}
+	passphraseKey := spec.PassphraseKey
+	if passphraseKey == "" {
+		passphraseKey = "passphrase"
+	}
+	privateKey, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s has no key %s", spec.SecretName, key)
+	}
+	knownHosts, ok := secret.Data[knownHostsKey]
+	if !ok {
+		return nil, fmt.Errorf("secret %s has no key %s", spec.SecretName, knownHostsKey)
+	}
+	return &git.SSHKey{
+		PrivateKey: privateKey,
+		Passphrase: secret.Data[passphraseKey],
+		KnownHosts: knownHosts,
+	}, nil
+}
+
+// readSigningKey reads the private key and the passphrase from the secret
+func (r *GitOpsReconciler) readSigningKey(ctx context.Context, namespace string, spec *gitopsv1.GitSigningKey, format git.SigningFormat) (*git.SigningKey, error) {
+	var secret corev1.Secret
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: spec.SecretName}, &secret); err != nil {
+		return nil, err
+	}
+
+	key := spec.Key
+	if key == "" {
+		key = "signing.key"
+	}
+	passphraseKey := spec.PassphraseKey
+	if passphraseKey == "" {
+		passphraseKey = "passphrase"
+	}
+	privateKey, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("secret %s has no key %s", spec.SecretName, key)
+	}
+	return &git.SigningKey{
+		Format:     format,
+		PrivateKey: privateKey,
+		Passphrase: secret.Data[passphraseKey],
+	}, nil
+}
+
+// readSecretKey reads the value of the key in the secret, which is nil if the optional secret or key does not exist
+func (r *GitOpsReconciler) readSecretKey(ctx context.Context, namespace string, selector *corev1.SecretKeySelector) ([]byte, error) {
+	optional := selector.Optional != nil && *selector.Optional
+	var secret corev1.Secret
+	if err := r.APIReader.Get(ctx, types.NamespacedName{Namespace: namespace, Name: selector.Name}, &secret); err != nil {
+		if optional && apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok && !optional {
+		return nil, fmt.Errorf("secret %s has no key %s", selector.Name, selector.Key)
+	}
+	return value, nil
+}
+
+// registryCredentials returns the credentials of registry_password_secret_ref, or else the global credentials
+// for Docker Hub or the registry of GITOPS_REGISTRY_HOST, which are not sent to the other registries
+func (r *GitOpsReconciler) registryCredentials(ctx context.Context, namespace string, image *gitopsv1.ImageSpec, regType registry.RegType) (registry.BasicCred, error) {
+	switch {
+	case image.RegistryPasswordSecretRef != nil:
+		password, err := r.readSecretKey(ctx, namespace, image.RegistryPasswordSecretRef)
+		if err != nil {
+			return registry.BasicCred{}, err
+		}
+		return registry.BasicCred{
+			Username: image.RegistryUsername,
+			Password: strings.TrimSpace(string(password)),
+		}, nil
+	case regType == registry.RegDockerHub:
+		// Docker Hub issues the token on another host (auth.docker.io), so the credentials are not bound to the host
+		return registry.BasicCred{
+			Username: r.DockerHubUsername,
+			Password: r.DockerHubPassword,
+		}, nil
+	case r.RegHost != "":
+		return registry.BasicCred{
+			Username: r.RegUsername,
+			Password: r.RegPassword,
+			Host:     r.RegHost,
+		}, nil
+	default:
+		return registry.BasicCred{}, nil
+	}
+}
+
+// secretNames returns the names of the secrets which the spec refers to
+func secretNames(spec *gitopsv1.GitOpsSpec) []string {
+	names := make([]string, 0)
+	for _, image := range append([]gitopsv1.ImageSpec{spec.ImageSpec}, spec.Images...) {
+		if image.RegistryPasswordSecretRef != nil {
+			names = append(names, image.RegistryPasswordSecretRef.Name)
+		}
+		if image.GCPCredentialsSecretRef != nil {
+			names = append(names, image.GCPCredentialsSecretRef.Name)
+		}
+		if image.AzureClientSecretRef != nil {
+			names = append(names, image.AzureClientSecretRef.Name)
+		}
+	}
+	if spec.GitCredentialsSecretRef != nil {
+		names = append(names, spec.GitCredentialsSecretRef.Name)
+	}
+	if spec.GitSSHKey != nil {
+		names = append(names, spec.GitSSHKey.SecretName)
+	}
+	if spec.GitSigningKey != nil {
+		names = append(names, spec.GitSigningKey.SecretName)
+	}
+	return names
+}
+
+// gitOpsForSecret maps the secret to the GitOps resources which refer to it,
+// so that rotating the secret triggers a reconcile
+func (r *GitOpsReconciler) gitOpsForSecret(obj handler.MapObject) []reconcile.Request {
+	var gitOpsList gitopsv1.GitOpsList
+	if err := r.List(context.Background(), &gitOpsList, client.InNamespace(obj.Meta.GetNamespace())); err != nil {
+		r.Log.Error(err, "unable to list GitOps for secret", "secret", obj.Meta.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0)
+	for _, gitOps := range gitOpsList.Items {
+		for _, name := range secretNames(&gitOps.Spec) {
+			if name == obj.Meta.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Namespace: gitOps.Namespace, Name: gitOps.Name},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
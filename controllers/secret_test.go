@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kazylla/gitops-controller/controllers/git"
+	"github.com/kazylla/gitops-controller/controllers/registry"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gitopsv1 "github.com/kazylla/gitops-controller/api/v1"
+)
+
+func TestCredentialsFromSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret corev1.Secret
+		result *gitCredentials
+	}{
+		{
+			"basic auth",
+			corev1.Secret{
+				Type: corev1.SecretTypeBasicAuth,
+				Data: map[string][]byte{"username": []byte("user"), "password": []byte("password")},
+			},
+			&gitCredentials{Username: "user", Password: "password"},
+		},
+		{
+			"token",
+			corev1.Secret{
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"token": []byte("token")},
+			},
+			&gitCredentials{Username: "git", Password: "token", TokenAuth: true},
+		},
+		{
+			"token with username",
+			corev1.Secret{
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"username": []byte("user"), "token": []byte("token")},
+			},
+			&gitCredentials{Username: "user", Password: "token", TokenAuth: true},
+		},
+		{
+			"ssh auth",
+			corev1.Secret{
+				Type: corev1.SecretTypeSSHAuth,
+				Data: map[string][]byte{"ssh-privatekey": []byte("key"), "known_hosts": []byte("github.com ssh-rsa AAAA")},
+			},
+			&gitCredentials{SSHKey: &git.SSHKey{PrivateKey: []byte("key"), KnownHosts: []byte("github.com ssh-rsa AAAA")}},
+		},
+		{
+			"ssh auth with token",
+			corev1.Secret{
+				Type: corev1.SecretTypeSSHAuth,
+				Data: map[string][]byte{"ssh-privatekey": []byte("key"), "known_hosts": []byte("github.com ssh-rsa AAAA"), "token": []byte("token")},
+			},
+			&gitCredentials{
+				Username:  "git",
+				Password:  "token",
+				TokenAuth: true,
+				SSHKey:    &git.SSHKey{PrivateKey: []byte("key"), KnownHosts: []byte("github.com ssh-rsa AAAA")},
+			},
+		},
+		{
+			"github app",
+			corev1.Secret{
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"app_id": []byte("12345"), "installation_id": []byte("67890\n"), "private_key": []byte("key")},
+			},
+			&gitCredentials{GitHubApp: &git.GitHubApp{AppID: 12345, InstallationID: 67890, PrivateKey: []byte("key")}},
+		},
+		{
+			"github app without installation_id",
+			corev1.Secret{
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"app_id": []byte("12345"), "private_key": []byte("key")},
+			},
+			nil,
+		},
+		{
+			"basic auth without password",
+			corev1.Secret{
+				Type: corev1.SecretTypeBasicAuth,
+				Data: map[string][]byte{"username": []byte("user")},
+			},
+			nil,
+		},
+		{
+			"no credentials",
+			corev1.Secret{
+				Type: corev1.SecretTypeOpaque,
+				Data: map[string][]byte{"foo": []byte("bar")},
+			},
+			nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.secret.ObjectMeta = metav1.ObjectMeta{Name: "git-credentials"}
+			result, err := credentialsFromSecret(&test.secret)
+			if test.result == nil {
+				if err == nil {
+					t.Errorf("expected error, got %v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("expected %v, got %v", test.result, result)
+			}
+		})
+	}
+}
+
+func TestRegistryCredentials(t *testing.T) {
+	r := &GitOpsReconciler{
+		APIReader: fake.NewFakeClient(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "registry"},
+			Data:       map[string][]byte{"password": []byte("image-password\n")},
+		}),
+		RegHost:           "registry.example.com",
+		RegUsername:       "reg-user",
+		RegPassword:       "reg-password",
+		DockerHubUsername: "hub-user",
+		DockerHubPassword: "hub-password",
+	}
+	passwordRef := &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "registry"}, Key: "password"}
+
+	tests := []struct {
+		name    string
+		image   gitopsv1.ImageSpec
+		regType registry.RegType
+		result  registry.BasicCred
+	}{
+		{
+			"image credentials",
+			gitopsv1.ImageSpec{RegistryUsername: "image-user", RegistryPasswordSecretRef: passwordRef},
+			registry.RegDockerHub,
+			registry.BasicCred{Username: "image-user", Password: "image-password"},
+		},
+		{
+			"docker hub",
+			gitopsv1.ImageSpec{},
+			registry.RegDockerHub,
+			registry.BasicCred{Username: "hub-user", Password: "hub-password"},
+		},
+		{
+			"docker registry",
+			gitopsv1.ImageSpec{},
+			registry.RegDocker,
+			registry.BasicCred{Username: "reg-user", Password: "reg-password", Host: "registry.example.com"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := r.registryCredentials(context.Background(), "default", &test.image, test.regType)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if result != test.result {
+				t.Errorf("expected %v, got %v", test.result, result)
+			}
+		})
+	}
+
+	// the global credentials are not sent anywhere without GITOPS_REGISTRY_HOST
+	r.RegHost = ""
+	result, err := r.registryCredentials(context.Background(), "default", &gitopsv1.ImageSpec{}, registry.RegDocker)
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	if result != (registry.BasicCred{}) {
+		t.Errorf("expected no credentials, got %v", result)
+	}
+}
+
+func TestSecretNames(t *testing.T) {
+	spec := gitopsv1.GitOpsSpec{
+		GitCredentialsSecretRef: &corev1.LocalObjectReference{Name: "git-credentials"},
+		Images: []gitopsv1.ImageSpec{
+			{RegistryPasswordSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "registry"}, Key: "password"}},
+			{GCPCredentialsSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "gcp"}, Key: "key.json"}},
+			{AzureClientSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "azure"}, Key: "client_secret"}},
+		},
+	}
+	expected := []string{"registry", "gcp", "azure", "git-credentials"}
+	if result := secretNames(&spec); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
@@ -0,0 +1,144 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// calVerSegments are the layout segments and the validation rule of each
+var calVerSegments = map[string]struct {
+	// padded requires the zero padded two digits (or four digits for YYYY)
+	padded   bool
+	digits   int
+	min, max int
+}{
+	"YYYY":  {padded: true, digits: 4, min: 0, max: 9999},
+	"YY":    {min: 0, max: 999},
+	"0Y":    {padded: true, digits: 2, min: 0, max: 999},
+	"MM":    {min: 1, max: 12},
+	"0M":    {padded: true, digits: 2, min: 1, max: 12},
+	"WW":    {min: 1, max: 53},
+	"0W":    {padded: true, digits: 2, min: 1, max: 53},
+	"DD":    {min: 1, max: 31},
+	"0D":    {padded: true, digits: 2, min: 1, max: 31},
+	"MAJOR": {min: 0, max: -1},
+	"MINOR": {min: 0, max: -1},
+	"MICRO": {min: 0, max: -1},
+}
+
+type CalVerImageVersion struct {
+	tag     string
+	numbers []int
+	build   string
+	opts    Options
+}
+
+// NewCalVerOptions validates the calendar version layout such as "YYYY.0M.0D" or "YY.0M.MICRO"
+func NewCalVerOptions(layout string) (Options, error) {
+	segments := strings.Split(layout, ".")
+	for _, s := range segments {
+		if _, ok := calVerSegments[s]; !ok {
+			return Options{}, fmt.Errorf("invalid calver layout: %s", layout)
+		}
+	}
+	return Options{
+		CalVerLayout: segments,
+	}, nil
+}
+
+// parseCalVerTag parses the calendar version tag into the numbers of the segments and the optional build suffix
+func parseCalVerTag(tag string, opts Options) ([]int, string, error) {
+	if len(opts.CalVerLayout) == 0 {
+		return nil, "", fmt.Errorf("no layout for calver tag format")
+	}
+
+	main := strings.TrimPrefix(tag, "v")
+	var build string
+	if i := strings.Index(main, "-"); i >= 0 {
+		main, build = main[:i], main[i+1:]
+		if build == "" {
+			return nil, "", fmt.Errorf("invalid tag format for calver: %s", tag)
+		}
+	}
+
+	parts := strings.Split(main, ".")
+	if len(parts) != len(opts.CalVerLayout) {
+		return nil, "", fmt.Errorf("invalid tag format for calver: %s", tag)
+	}
+	numbers := make([]int, 0, len(parts))
+	for i, part := range parts {
+		rule := calVerSegments[opts.CalVerLayout[i]]
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return nil, "", fmt.Errorf("invalid tag format for calver: %s", tag)
+		}
+		if rule.padded && len(part) < rule.digits {
+			return nil, "", fmt.Errorf("invalid tag format for calver: %s (%s must be zero padded)", tag, opts.CalVerLayout[i])
+		}
+		if !rule.padded && len(part) > 1 && part[0] == '0' {
+			return nil, "", fmt.Errorf("invalid tag format for calver: %s (%s must not be zero padded)", tag, opts.CalVerLayout[i])
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, "", err
+		}
+		if n < rule.min || (rule.max >= 0 && n > rule.max) {
+			return nil, "", fmt.Errorf("invalid tag format for calver: %s (%s is out of range)", tag, opts.CalVerLayout[i])
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, build, nil
+}
+
+// compareBuild compares the build suffixes, where a tag without the suffix is older
+func compareBuild(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return -1
+	case b == "":
+		return 1
+	case strings.Trim(a, "0123456789") == "" && strings.Trim(b, "0123456789") == "":
+		return compareNumerical(a, b)
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// NewCalVerImageVersion creates CalVerImageVersion
+func NewCalVerImageVersion(tag string, opts Options) (*CalVerImageVersion, error) {
+	v := &CalVerImageVersion{}
+
+	var err error
+	v.tag = tag
+	v.opts = opts
+	v.numbers, v.build, err = parseCalVerTag(tag, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// GetTag is a getter for getting tag members
+func (v *CalVerImageVersion) GetTag() string {
+	return v.tag
+}
+
+// Compare compares with the specified tag
+func (v *CalVerImageVersion) Compare(anotherTag string) (int, error) {
+	anotherNumbers, anotherBuild, err := parseCalVerTag(anotherTag, v.opts)
+	if err != nil {
+		return 0, err
+	}
+	for i := range v.numbers {
+		switch {
+		case anotherNumbers[i] < v.numbers[i]:
+			return 1, nil
+		case anotherNumbers[i] > v.numbers[i]:
+			return -1, nil
+		}
+	}
+	return compareBuild(v.build, anotherBuild), nil
+}
@@ -0,0 +1,152 @@
+package version
+
+import (
+	"testing"
+)
+
+func TestCalVerImageVersion_Compare(t *testing.T) {
+	tests := []struct {
+		name       string
+		layout     string
+		currentTag string
+		newTag     string
+		result     int
+	}{
+		{
+			"currentTag is equal to newTag",
+			"YYYY.MM.MICRO",
+			"2024.6.1",
+			"2024.6.1",
+			0,
+		},
+		{
+			"currentTag is newer than newTag",
+			"YYYY.MM.MICRO",
+			"2024.6.1",
+			"2024.5.3",
+			1,
+		},
+		{
+			"newTag is newer than currentTag (month)",
+			"YYYY.MM.MICRO",
+			"2024.6.1",
+			"2024.11.0",
+			-1,
+		},
+		{
+			"newTag is newer than currentTag (micro)",
+			"YYYY.MM.MICRO",
+			"2024.6.9",
+			"2024.6.10",
+			-1,
+		},
+		{
+			"newTag is newer than currentTag (year)",
+			"YY.0M.0D",
+			"24.12.31",
+			"25.01.01",
+			-1,
+		},
+		{
+			"newTag is newer than currentTag (build suffix)",
+			"YY.0M.0D",
+			"24.06.03",
+			"24.06.03-2",
+			-1,
+		},
+		{
+			"newTag is newer than currentTag (numerical build suffix)",
+			"YY.0M.0D",
+			"24.06.03-2",
+			"24.06.03-10",
+			-1,
+		},
+		{
+			"currentTag is newer than newTag (date over build suffix)",
+			"YY.0M.0D",
+			"24.06.04",
+			"24.06.03-2",
+			1,
+		},
+		{
+			"currentTag is equal to newTag (v prefix)",
+			"YYYY.0M.MICRO",
+			"v2024.06.1",
+			"2024.06.1",
+			0,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := NewCalVerOptions(test.layout)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			ver, err := NewCalVerImageVersion(test.currentTag, opts)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			result, err := ver.Compare(test.newTag)
+			if err != nil {
+				t.Errorf("got unexpected error: %s", err.Error())
+			}
+			if result != test.result {
+				t.Errorf("expected %d, got %d", test.result, result)
+			}
+		})
+	}
+}
+
+func TestCalVerImageVersion_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		layout string
+		tag    string
+	}{
+		{
+			"too few segments",
+			"YYYY.MM.DD",
+			"2024.06",
+		},
+		{
+			"zero padded month for MM",
+			"YYYY.MM.DD",
+			"2024.06.01",
+		},
+		{
+			"month without padding for 0M",
+			"YY.0M.MICRO",
+			"24.6.1",
+		},
+		{
+			"month out of range",
+			"YYYY.0M.0D",
+			"2024.13.01",
+		},
+		{
+			"not a number",
+			"YYYY.0M.MICRO",
+			"2024.06.x",
+		},
+		{
+			"empty build suffix",
+			"YYYY.0M.MICRO",
+			"2024.06.1-",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := NewCalVerOptions(test.layout)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if _, err := NewCalVerImageVersion(test.tag, opts); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+
+	if _, err := NewCalVerOptions("YYYY.MONTH"); err == nil {
+		t.Errorf("expected error for invalid layout, got nil")
+	}
+}
@@ -0,0 +1,226 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	version2 "k8s.io/apimachinery/pkg/util/version"
+)
+
+type comparator struct {
+	op  string
+	ver *version2.Version
+}
+
+// check checks whether the version satisfies the comparator
+func (c comparator) check(v *version2.Version) bool {
+	result, _ := v.Compare(c.ver.String())
+	switch c.op {
+	case "=":
+		return result == 0
+	case "!=":
+		return result != 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	default:
+		return false
+	}
+}
+
+// Constraint is a semantic version constraint such as ">=1.2.0 <2.0.0", "~1.4" or "^1.2 || ^2.0"
+type Constraint struct {
+	expr string
+	// comparators are ANDed in each alternative, and alternatives are ORed
+	alternatives [][]comparator
+}
+
+// partialVersion is a version whose minor and patch may be omitted or wildcards (e.g. "1", "1.4", "1.4.x")
+type partialVersion struct {
+	major, minor, patch int
+	preRelease          string
+	// specified is the number of specified parts (0-3)
+	specified int
+}
+
+// semVer converts partialVersion into version2.Version with the unspecified parts as 0
+func (p partialVersion) semVer(preRelease string) *version2.Version {
+	s := fmt.Sprintf("%d.%d.%d", p.major, p.minor, p.patch)
+	if preRelease != "" {
+		s += "-" + preRelease
+	}
+	return version2.MustParseSemantic(s)
+}
+
+// upperBound returns the lowest version which excludes all pre-releases of the specified version
+func upperBound(major, minor, patch int) *version2.Version {
+	return partialVersion{major: major, minor: minor, patch: patch}.semVer("0")
+}
+
+// parsePartialVersion parses the version in the constraint
+func parsePartialVersion(s string) (partialVersion, error) {
+	p := partialVersion{}
+	s = strings.TrimPrefix(s, "v")
+
+	if i := strings.Index(s, "-"); i >= 0 {
+		p.preRelease = s[i+1:]
+		s = s[:i]
+	}
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 || s == "" {
+		return p, fmt.Errorf("invalid version in constraint: %s", s)
+	}
+	nums := []*int{&p.major, &p.minor, &p.patch}
+	for i, part := range parts {
+		if part == "x" || part == "X" || part == "*" {
+			break
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("invalid version in constraint: %s", s)
+		}
+		*nums[i] = n
+		p.specified = i + 1
+	}
+	if p.preRelease != "" && p.specified != 3 {
+		return p, fmt.Errorf("pre-release requires full version in constraint: %s", s)
+	}
+	if p.preRelease != "" {
+		// semVer must not fail on the pre-release such as "rc_1" or "01"
+		if _, err := version2.ParseSemantic(fmt.Sprintf("%d.%d.%d-%s", p.major, p.minor, p.patch, p.preRelease)); err != nil {
+			return p, fmt.Errorf("invalid pre-release in constraint: %s", p.preRelease)
+		}
+	}
+	return p, nil
+}
+
+// parseComparator expands one constraint term into the primitive comparators
+func parseComparator(term string) ([]comparator, error) {
+	op := ""
+	for _, o := range []string{">=", "<=", "!=", ">", "<", "=", "~", "^"} {
+		if strings.HasPrefix(term, o) {
+			op = o
+			break
+		}
+	}
+	p, err := parsePartialVersion(strings.TrimSpace(strings.TrimPrefix(term, op)))
+	if err != nil {
+		return nil, err
+	}
+	low := p.semVer(p.preRelease)
+
+	switch op {
+	case "", "=":
+		switch p.specified {
+		case 0:
+			return []comparator{}, nil
+		case 1:
+			return []comparator{{">=", low}, {"<", upperBound(p.major+1, 0, 0)}}, nil
+		case 2:
+			return []comparator{{">=", low}, {"<", upperBound(p.major, p.minor+1, 0)}}, nil
+		default:
+			return []comparator{{"=", low}}, nil
+		}
+	case "!=", ">=", "<":
+		return []comparator{{op, low}}, nil
+	case ">":
+		switch p.specified {
+		case 1:
+			return []comparator{{">=", upperBound(p.major+1, 0, 0)}}, nil
+		case 2:
+			return []comparator{{">=", upperBound(p.major, p.minor+1, 0)}}, nil
+		default:
+			return []comparator{{">", low}}, nil
+		}
+	case "<=":
+		switch p.specified {
+		case 1:
+			return []comparator{{"<", upperBound(p.major+1, 0, 0)}}, nil
+		case 2:
+			return []comparator{{"<", upperBound(p.major, p.minor+1, 0)}}, nil
+		default:
+			return []comparator{{"<=", low}}, nil
+		}
+	case "~":
+		// ~1.4.2 := >=1.4.2 <1.5.0, ~1.4 := >=1.4.0 <1.5.0, ~1 := >=1.0.0 <2.0.0
+		if p.specified <= 1 {
+			return []comparator{{">=", low}, {"<", upperBound(p.major+1, 0, 0)}}, nil
+		}
+		return []comparator{{">=", low}, {"<", upperBound(p.major, p.minor+1, 0)}}, nil
+	case "^":
+		// ^1.4.2 := >=1.4.2 <2.0.0, ^0.4.2 := >=0.4.2 <0.5.0, ^0.0.3 := >=0.0.3 <0.0.4
+		switch {
+		case p.major != 0 || p.specified <= 1:
+			return []comparator{{">=", low}, {"<", upperBound(p.major+1, 0, 0)}}, nil
+		case p.minor != 0 || p.specified == 2:
+			return []comparator{{">=", low}, {"<", upperBound(0, p.minor+1, 0)}}, nil
+		default:
+			return []comparator{{">=", low}, {"<", upperBound(0, 0, p.patch+1)}}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid constraint: %s", term)
+}
+
+// ParseConstraint parses the constraint expression
+func ParseConstraint(expr string) (*Constraint, error) {
+	c := &Constraint{expr: expr}
+	for _, alt := range strings.Split(expr, "||") {
+		// join the operator and the version separated by spaces (e.g. ">= 1.2.0")
+		fields := strings.FieldsFunc(alt, func(r rune) bool { return r == ' ' || r == ',' })
+		terms := make([]string, 0)
+		for i := 0; i < len(fields); i++ {
+			term := fields[i]
+			if strings.Trim(term, "<>=!~^") == "" && i+1 < len(fields) {
+				i++
+				term += fields[i]
+			}
+			terms = append(terms, term)
+		}
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("invalid constraint: %s", expr)
+		}
+
+		comparators := make([]comparator, 0)
+		for _, term := range terms {
+			cs, err := parseComparator(term)
+			if err != nil {
+				return nil, err
+			}
+			comparators = append(comparators, cs...)
+		}
+		c.alternatives = append(c.alternatives, comparators)
+	}
+	return c, nil
+}
+
+// String returns the constraint expression
+func (c *Constraint) String() string {
+	return c.expr
+}
+
+// Check checks whether the semantic version satisfies the constraint
+func (c *Constraint) Check(v *SemanticImageVersion) bool {
+	for _, comparators := range c.alternatives {
+		ok := true
+		for _, comp := range comparators {
+			if !comp.check(v.semVer) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package version
+
+import (
+	"testing"
+)
+
+func TestConstraint_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		constraint string
+		tag        string
+		result     bool
+	}{
+		{
+			"within range",
+			">=1.2.0 <2.0.0",
+			"v1.5.3",
+			true,
+		},
+		{
+			"lower than range",
+			">=1.2.0 <2.0.0",
+			"v1.1.9",
+			false,
+		},
+		{
+			"major bump is out of range",
+			">=1.2.0 <2.0.0",
+			"v2.0.0",
+			false,
+		},
+		{
+			"range separated by comma and spaces",
+			">= 1.2.0, < 2.0.0",
+			"v1.2.0",
+			true,
+		},
+		{
+			"tilde allows patch",
+			"~1.4",
+			"v1.4.9",
+			true,
+		},
+		{
+			"tilde rejects minor",
+			"~1.4",
+			"v1.5.0",
+			false,
+		},
+		{
+			"tilde with patch",
+			"~1.4.2",
+			"v1.4.1",
+			false,
+		},
+		{
+			"caret allows minor",
+			"^1.2",
+			"v1.9.0",
+			true,
+		},
+		{
+			"caret rejects major",
+			"^1.2",
+			"v2.0.0",
+			false,
+		},
+		{
+			"caret on 0.x rejects minor",
+			"^0.4.2",
+			"v0.5.0",
+			false,
+		},
+		{
+			"wildcard",
+			"1.x",
+			"v1.9.9",
+			true,
+		},
+		{
+			"pre-release of upper bound is excluded",
+			"~1.4",
+			"v1.5.0-rc.1",
+			false,
+		},
+		{
+			"alternatives",
+			"^1.2 || ^3.0",
+			"v3.1.0",
+			true,
+		},
+		{
+			"not equal",
+			"^1.2 !=1.3.0",
+			"v1.3.0",
+			false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := ParseConstraint(test.constraint)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			ver, err := NewSemanticImageVersion(test.tag)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if result := c.Check(ver); result != test.result {
+				t.Errorf("expected %t, got %t", test.result, result)
+			}
+		})
+	}
+}
+
+func TestParseConstraint_Invalid(t *testing.T) {
+	for _, constraint := range []string{"", ">=1.2.3.4", "~a.b", ">=1.2-rc.1", ">=1.2.0-rc_1", ">=1.2.0-01"} {
+		t.Run(constraint, func(t *testing.T) {
+			if _, err := ParseConstraint(constraint); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+}
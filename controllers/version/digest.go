@@ -0,0 +1,78 @@
+package version
+
+import (
+	"strings"
+)
+
+// Digester is implemented by ImageVersion which pins the image by the manifest digest
+type Digester interface {
+	GetDigest() string
+}
+
+// DigestImageVersion is a mutable tag (e.g. "latest") followed by the manifest digest
+type DigestImageVersion struct {
+	tag    string
+	digest string
+}
+
+// SplitDigest splits the image reference "<tag>@<digest>" into the tag and the digest
+func SplitDigest(ref string) (string, string) {
+	parts := strings.SplitN(ref, "@", 2)
+	if len(parts) != 2 {
+		return ref, ""
+	}
+	return parts[0], parts[1]
+}
+
+// NewDigestImageVersion creates DigestImageVersion
+func NewDigestImageVersion(tag, digest string) *DigestImageVersion {
+	return &DigestImageVersion{
+		tag:    tag,
+		digest: digest,
+	}
+}
+
+// GetTag is a getter for getting tag members
+func (v *DigestImageVersion) GetTag() string {
+	return v.tag
+}
+
+// GetDigest is a getter for getting digest members
+func (v *DigestImageVersion) GetDigest() string {
+	return v.digest
+}
+
+// Compare compares with the specified tag by the name only, since the followed tag is always the newest.
+// Whether the digest has changed is up to the caller.
+func (v *DigestImageVersion) Compare(anotherTag string) (int, error) {
+	anotherTag, _ = SplitDigest(anotherTag)
+	if anotherTag == v.tag {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// PinnedImageVersion is ImageVersion pinned by the manifest digest
+type PinnedImageVersion struct {
+	ImageVersion
+	digest string
+}
+
+// WithDigest pins ImageVersion by the manifest digest
+func WithDigest(v ImageVersion, digest string) *PinnedImageVersion {
+	return &PinnedImageVersion{
+		ImageVersion: v,
+		digest:       digest,
+	}
+}
+
+// GetDigest is a getter for getting digest members
+func (v *PinnedImageVersion) GetDigest() string {
+	return v.digest
+}
+
+// Compare compares with the specified tag, ignoring the digest of the tag
+func (v *PinnedImageVersion) Compare(anotherTag string) (int, error) {
+	anotherTag, _ = SplitDigest(anotherTag)
+	return v.ImageVersion.Compare(anotherTag)
+}
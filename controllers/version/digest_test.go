@@ -0,0 +1,63 @@
+package version
+
+import (
+	"testing"
+)
+
+func TestDigestImageVersion_Compare(t *testing.T) {
+	tests := []struct {
+		name       string
+		currentTag string
+		newTag     string
+		result     int
+	}{
+		{
+			"currentTag is equal to newTag",
+			"latest",
+			"latest",
+			0,
+		},
+		{
+			"currentTag is equal to newTag (ignore digest)",
+			"latest",
+			"latest@sha256:0000",
+			0,
+		},
+		{
+			"currentTag is newer than another tag",
+			"stable",
+			"v1.0.0",
+			1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ver := NewDigestImageVersion(test.currentTag, "sha256:1111")
+			result, err := ver.Compare(test.newTag)
+			if err != nil {
+				t.Errorf("got unexpected error: %s", err.Error())
+			}
+			if result != test.result {
+				t.Errorf("expected %d, got %d", test.result, result)
+			}
+		})
+	}
+}
+
+func TestPinnedImageVersion_Compare(t *testing.T) {
+	ver, err := NewSemanticImageVersion("v1.1.0")
+	if err != nil {
+		t.Fatalf("got unexpected error: %s", err.Error())
+	}
+	pinned := WithDigest(ver, "sha256:1111")
+	if pinned.GetTag() != "v1.1.0" || pinned.GetDigest() != "sha256:1111" {
+		t.Errorf("expected v1.1.0@sha256:1111, got %s@%s", pinned.GetTag(), pinned.GetDigest())
+	}
+	result, err := pinned.Compare("v1.0.0@sha256:0000")
+	if err != nil {
+		t.Errorf("got unexpected error: %s", err.Error())
+	}
+	if result != 1 {
+		t.Errorf("expected 1, got %d", result)
+	}
+}
@@ -1,10 +1,18 @@
 package version
 
+import (
+	"regexp"
+	"time"
+)
+
 type TagFormat int
 
 const (
 	TagFormatSerial TagFormat = iota
 	TagFormatSemantic
+	TagFormatRegex
+	TagFormatTimestamp
+	TagFormatCalVer
 )
 
 type ImageVersion interface {
@@ -12,8 +20,21 @@ type ImageVersion interface {
 	Compare(string) (int, error)
 }
 
+// Options holds the parameters for the tag formats which need them
+type Options struct {
+	// TagFormatRegex
+	Regex       *regexp.Regexp
+	RegexGroups []RegexGroup
+
+	// TagFormatTimestamp
+	PushedAt map[string]time.Time
+
+	// TagFormatCalVer
+	CalVerLayout []string
+}
+
 // NewImageVersion creates ImageVersion according to TagFormat
-func NewImageVersion(tag string, tagFmt TagFormat) (ImageVersion, error) {
+func NewImageVersion(tag string, tagFmt TagFormat, opts Options) (ImageVersion, error) {
 	var err error
 	var imageVer ImageVersion
 
@@ -28,6 +49,21 @@ func NewImageVersion(tag string, tagFmt TagFormat) (ImageVersion, error) {
 		if err != nil {
 			return nil, err
 		}
+	case tagFmt == TagFormatRegex:
+		imageVer, err = NewRegexImageVersion(tag, opts)
+		if err != nil {
+			return nil, err
+		}
+	case tagFmt == TagFormatTimestamp:
+		imageVer, err = NewTimestampImageVersion(tag, opts)
+		if err != nil {
+			return nil, err
+		}
+	case tagFmt == TagFormatCalVer:
+		imageVer, err = NewCalVerImageVersion(tag, opts)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return imageVer, nil
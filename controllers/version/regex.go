@@ -0,0 +1,147 @@
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type RegexOrder int
+
+const (
+	RegexOrderNumerical RegexOrder = iota
+	RegexOrderLexical
+)
+
+type RegexGroup struct {
+	Name  string
+	Order RegexOrder
+}
+
+type RegexImageVersion struct {
+	tag    string
+	values []string
+	opts   Options
+}
+
+// NewRegexOptions compiles the pattern that must match the whole tag, and validates the capture groups.
+// If no groups are specified, all named groups of the pattern are compared numerically in order.
+func NewRegexOptions(pattern string, groups []RegexGroup) (Options, error) {
+	re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+	if err != nil {
+		return Options{}, err
+	}
+
+	names := make(map[string]bool)
+	for _, name := range re.SubexpNames() {
+		if name != "" {
+			names[name] = true
+		}
+	}
+	if len(groups) == 0 {
+		for _, name := range re.SubexpNames() {
+			if name != "" {
+				groups = append(groups, RegexGroup{Name: name, Order: RegexOrderNumerical})
+			}
+		}
+	}
+	if len(groups) == 0 {
+		return Options{}, fmt.Errorf("no named capture group in pattern: %s", pattern)
+	}
+	for _, g := range groups {
+		if !names[g.Name] {
+			return Options{}, fmt.Errorf("capture group %s is not found in pattern: %s", g.Name, pattern)
+		}
+	}
+
+	return Options{
+		Regex:       re,
+		RegexGroups: groups,
+	}, nil
+}
+
+// parseRegexTag matches the tag with the pattern and returns the values of the capture groups
+func parseRegexTag(tag string, opts Options) ([]string, error) {
+	if opts.Regex == nil {
+		return nil, fmt.Errorf("no pattern for regex tag format")
+	}
+	match := opts.Regex.FindStringSubmatch(tag)
+	if match == nil {
+		return nil, fmt.Errorf("invalid tag format for regex: %s", tag)
+	}
+
+	values := make([]string, 0, len(opts.RegexGroups))
+	for _, g := range opts.RegexGroups {
+		value := match[subexpIndex(opts.Regex, g.Name)]
+		if g.Order == RegexOrderNumerical && strings.Trim(value, "0123456789") != "" {
+			return nil, fmt.Errorf("invalid tag format for regex: %s (%s is not numerical)", tag, g.Name)
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// subexpIndex returns the index of the named capture group
+func subexpIndex(re *regexp.Regexp, name string) int {
+	for i, n := range re.SubexpNames() {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// compareNumerical compares decimal digit strings of arbitrary length
+func compareNumerical(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+// NewRegexImageVersion creates RegexImageVersion
+func NewRegexImageVersion(tag string, opts Options) (*RegexImageVersion, error) {
+	v := &RegexImageVersion{}
+
+	var err error
+	v.tag = tag
+	v.opts = opts
+	v.values, err = parseRegexTag(tag, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// GetTag is a getter for getting tag members
+func (v *RegexImageVersion) GetTag() string {
+	return v.tag
+}
+
+// Compare compares with the specified tag
+func (v *RegexImageVersion) Compare(anotherTag string) (int, error) {
+	anotherValues, err := parseRegexTag(anotherTag, v.opts)
+	if err != nil {
+		return 0, err
+	}
+	for i, g := range v.opts.RegexGroups {
+		var result int
+		switch g.Order {
+		case RegexOrderLexical:
+			result = strings.Compare(v.values[i], anotherValues[i])
+		default:
+			result = compareNumerical(v.values[i], anotherValues[i])
+		}
+		if result != 0 {
+			return result, nil
+		}
+	}
+	return 0, nil
+}
@@ -0,0 +1,116 @@
+package version
+
+import (
+	"testing"
+)
+
+func TestRegexImageVersion_Compare(t *testing.T) {
+	tests := []struct {
+		name       string
+		pattern    string
+		groups     []RegexGroup
+		currentTag string
+		newTag     string
+		result     int
+	}{
+		{
+			"currentTag is equal to newTag",
+			`release-(?P<date>\d{4}\.\d{2}\.\d{2})-[0-9a-f]+`,
+			[]RegexGroup{{Name: "date", Order: RegexOrderLexical}},
+			"release-2024.05.03-abc123",
+			"release-2024.05.03-def456",
+			0,
+		},
+		{
+			"newTag is newer than currentTag (lexical)",
+			`release-(?P<date>\d{4}\.\d{2}\.\d{2})-[0-9a-f]+`,
+			[]RegexGroup{{Name: "date", Order: RegexOrderLexical}},
+			"release-2024.05.03-abc123",
+			"release-2024.06.01-def456",
+			-1,
+		},
+		{
+			"newTag is newer than currentTag (numerical)",
+			`(?P<major>\d+)\.(?P<minor>\d+)\.(?P<patch>\d+)-alpine`,
+			nil,
+			"1.4.2-alpine",
+			"1.4.10-alpine",
+			-1,
+		},
+		{
+			"currentTag is newer than newTag (numerical)",
+			`(?P<major>\d+)\.(?P<minor>\d+)\.(?P<patch>\d+)-alpine`,
+			nil,
+			"1.10.0-alpine",
+			"1.9.9-alpine",
+			1,
+		},
+		{
+			"compare groups in the specified order",
+			`(?P<build>\d+)-(?P<date>\d{8})`,
+			[]RegexGroup{{Name: "date"}, {Name: "build"}},
+			"2-20240503",
+			"1-20240504",
+			-1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := NewRegexOptions(test.pattern, test.groups)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			ver, err := NewRegexImageVersion(test.currentTag, opts)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			result, err := ver.Compare(test.newTag)
+			if err != nil {
+				t.Errorf("got unexpected error: %s", err.Error())
+			}
+			if result != test.result {
+				t.Errorf("expected %d, got %d", test.result, result)
+			}
+		})
+	}
+}
+
+func TestRegexImageVersion_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		groups  []RegexGroup
+		tag     string
+	}{
+		{
+			"tag does not match the whole pattern",
+			`(?P<num>\d+)`,
+			nil,
+			"v1",
+		},
+		{
+			"numerical group is not a number",
+			`(?P<num>\w+)`,
+			nil,
+			"abc",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts, err := NewRegexOptions(test.pattern, test.groups)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			if _, err := NewRegexImageVersion(test.tag, opts); err == nil {
+				t.Errorf("expected error, got nil")
+			}
+		})
+	}
+
+	if _, err := NewRegexOptions(`\d+`, nil); err == nil {
+		t.Errorf("expected error for pattern without named group, got nil")
+	}
+	if _, err := NewRegexOptions(`(?P<num>\d+)`, []RegexGroup{{Name: "unknown"}}); err == nil {
+		t.Errorf("expected error for unknown group, got nil")
+	}
+}
@@ -39,3 +39,8 @@ func (v *SemanticImageVersion) GetTag() string {
 func (v *SemanticImageVersion) Compare(anotherTag string) (int, error) {
 	return v.semVer.Compare(anotherTag)
 }
+
+// IsPreRelease returns whether the version is a pre-release
+func (v *SemanticImageVersion) IsPreRelease() bool {
+	return v.semVer.PreRelease() != ""
+}
@@ -0,0 +1,57 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TimestampImageVersion struct {
+	tag      string
+	pushedAt time.Time
+	opts     Options
+}
+
+// NewTimestampImageVersion creates TimestampImageVersion with the push time reported by the registry
+func NewTimestampImageVersion(tag string, opts Options) (*TimestampImageVersion, error) {
+	pushedAt, ok := opts.PushedAt[tag]
+	if !ok || pushedAt.IsZero() {
+		return nil, fmt.Errorf("no push time for tag: %s", tag)
+	}
+
+	return &TimestampImageVersion{
+		tag:      tag,
+		pushedAt: pushedAt,
+		opts:     opts,
+	}, nil
+}
+
+// GetTag is a getter for getting tag members
+func (v *TimestampImageVersion) GetTag() string {
+	return v.tag
+}
+
+// GetPushedAt is a getter for getting the push time
+func (v *TimestampImageVersion) GetPushedAt() time.Time {
+	return v.pushedAt
+}
+
+// Compare compares with the specified tag by the push time, and by the tag name if they are pushed at the same time.
+// A tag which is no longer in the registry is regarded as older than any tag in the registry.
+func (v *TimestampImageVersion) Compare(anotherTag string) (int, error) {
+	if anotherTag == v.tag {
+		return 0, nil
+	}
+	anotherPushedAt, ok := v.opts.PushedAt[anotherTag]
+	if !ok || anotherPushedAt.IsZero() {
+		return 1, nil
+	}
+	switch {
+	case anotherPushedAt.Before(v.pushedAt):
+		return 1, nil
+	case anotherPushedAt.After(v.pushedAt):
+		return -1, nil
+	default:
+		return strings.Compare(v.tag, anotherTag), nil
+	}
+}
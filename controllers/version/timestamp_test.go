@@ -0,0 +1,72 @@
+package version
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestampImageVersion_Compare(t *testing.T) {
+	opts := Options{
+		PushedAt: map[string]time.Time{
+			"a1b2c3d": time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			"e4f5a6b": time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
+			"c7d8e9f": time.Date(2024, 5, 2, 10, 0, 0, 0, time.UTC),
+		},
+	}
+	tests := []struct {
+		name       string
+		currentTag string
+		newTag     string
+		result     int
+	}{
+		{
+			"currentTag is equal to newTag",
+			"a1b2c3d",
+			"a1b2c3d",
+			0,
+		},
+		{
+			"currentTag is newer than newTag",
+			"e4f5a6b",
+			"a1b2c3d",
+			1,
+		},
+		{
+			"newTag is newer than currentTag",
+			"a1b2c3d",
+			"e4f5a6b",
+			-1,
+		},
+		{
+			"pushed at the same time",
+			"e4f5a6b",
+			"c7d8e9f",
+			1,
+		},
+		{
+			"newTag is no longer in the registry",
+			"a1b2c3d",
+			"0000000",
+			1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ver, err := NewTimestampImageVersion(test.currentTag, opts)
+			if err != nil {
+				t.Fatalf("got unexpected error: %s", err.Error())
+			}
+			result, err := ver.Compare(test.newTag)
+			if err != nil {
+				t.Errorf("got unexpected error: %s", err.Error())
+			}
+			if result != test.result {
+				t.Errorf("expected %d, got %d", test.result, result)
+			}
+		})
+	}
+
+	if _, err := NewTimestampImageVersion("unknown", opts); err == nil {
+		t.Errorf("expected error for tag without push time, got nil")
+	}
+}
@@ -43,6 +43,11 @@ func main() {
 	envResyncPeriod := getenvInt("GITOPS_RESYNC_PERIOD", 30)
 	envGitUsername := getenv("GITOPS_GIT_USERNAME", "")
 	envGitPassword := getenv("GITOPS_GIT_PASSWORD", "")
+	envRegHost := getenv("GITOPS_REGISTRY_HOST", "")
+	envRegUsername := getenv("GITOPS_REGISTRY_USERNAME", "")
+	envRegPassword := getenv("GITOPS_REGISTRY_PASSWORD", "")
+	envDockerHubUsername := getenv("GITOPS_DOCKERHUB_USERNAME", "")
+	envDockerHubPassword := getenv("GITOPS_DOCKERHUB_PASSWORD", "")
 
 	ctrl.SetLogger(zap.New(func(o *zap.Options) {
 		o.Development = envDevelopment == "true"
@@ -53,6 +58,14 @@ func main() {
 	setupLog.Info("GITOPS_RESYNC_PERIOD", "value", envResyncPeriod)
 	setupLog.Info("GITOPS_GIT_USERNAME", "value", envGitUsername)
 	setupLog.Info("GITOPS_GIT_PASSWORD", "value", rep.ReplaceAllString(envGitPassword, "*"))
+	setupLog.Info("GITOPS_REGISTRY_HOST", "value", envRegHost)
+	setupLog.Info("GITOPS_REGISTRY_USERNAME", "value", envRegUsername)
+	setupLog.Info("GITOPS_REGISTRY_PASSWORD", "value", rep.ReplaceAllString(envRegPassword, "*"))
+	if envRegUsername != "" && envRegHost == "" {
+		setupLog.Info("GITOPS_REGISTRY_USERNAME is not used without GITOPS_REGISTRY_HOST")
+	}
+	setupLog.Info("GITOPS_DOCKERHUB_USERNAME", "value", envDockerHubUsername)
+	setupLog.Info("GITOPS_DOCKERHUB_PASSWORD", "value", rep.ReplaceAllString(envDockerHubPassword, "*"))
 
 	var resyncPeriod = time.Second * time.Duration(envResyncPeriod)
 
@@ -69,12 +82,18 @@ func main() {
 	}
 
 	if err = (&controllers.GitOpsReconciler{
-		Client:      mgr.GetClient(),
-		Log:         ctrl.Log.WithName("controllers").WithName("GitOps"),
-		Scheme:      mgr.GetScheme(),
-		GitUsername: envGitUsername,
-		GitPassword: envGitPassword,
-		Recorder:    mgr.GetEventRecorderFor("gitops-controller"),
+		Client:            mgr.GetClient(),
+		APIReader:         mgr.GetAPIReader(),
+		Log:               ctrl.Log.WithName("controllers").WithName("GitOps"),
+		Scheme:            mgr.GetScheme(),
+		GitUsername:       envGitUsername,
+		GitPassword:       envGitPassword,
+		RegHost:           envRegHost,
+		RegUsername:       envRegUsername,
+		RegPassword:       envRegPassword,
+		DockerHubUsername: envDockerHubUsername,
+		DockerHubPassword: envDockerHubPassword,
+		Recorder:          mgr.GetEventRecorderFor("gitops-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GitOps")
 		os.Exit(1)